@@ -0,0 +1,103 @@
+package golangci_lint_runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// apiClient is a minimal client for JSON REST APIs like the ones of GitLab and Gitea.
+type apiClient struct {
+	name    string
+	baseURL string
+	token   string
+	// header is the name of the header the token is sent in, the value is prefix + token
+	header     string
+	prefix     string
+	httpClient *http.Client
+}
+
+type apiError struct {
+	Name       string
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s responded with %d: %s", e.Name, e.StatusCode, e.Message)
+}
+
+// Is reports a 404 as ErrNotFound.
+func (e *apiError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// do sends the request to path (relative to the base url, already escaped) and decodes the response into out.
+// It returns the next page of the X-Next-Page header (0 if there is none).
+func (c *apiClient) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return 0, fmt.Errorf("unable to marshal request: %w", err)
+		}
+		body = bytes.NewReader(buf)
+	}
+	res, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return 0, fmt.Errorf("unable to decode response of %s %s: %w", method, path, err)
+		}
+	}
+	next, _ := strconv.Atoi(res.Header.Get("X-Next-Page"))
+	return next, nil
+}
+
+// raw returns the body of a GET request to path.
+func (c *apiClient) raw(ctx context.Context, path string) ([]byte, error) {
+	res, err := c.send(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	return ioutil.ReadAll(res.Body)
+}
+
+func (c *apiClient) send(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set(c.header, c.prefix+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		buf, _ := ioutil.ReadAll(io.LimitReader(res.Body, 4096))
+		return nil, &apiError{Name: c.name, StatusCode: res.StatusCode, Message: strings.TrimSpace(string(buf))}
+	}
+	return res, nil
+}
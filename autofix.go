@@ -0,0 +1,322 @@
+package golangci_lint_runner
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/golangci/golangci-lint/pkg/printers"
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/talon-one/golangci-lint-runner/internal/diff"
+	"gopkg.in/src-d/go-git.v4"
+	gitConfig "gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// DefaultAutofixLinters are the linters that are used for autofix if none are specified.
+var DefaultAutofixLinters = []string{"gofmt", "goimports", "misspell"}
+
+const (
+	autofixAuthorName    = "golangci-lint-runner"
+	autofixAuthorEmail   = "golangci-lint-runner@users.noreply.github.com"
+	autofixCommitMessage = "Apply golangci-lint fixes"
+)
+
+// autofix runs golangci-lint --fix for all fixable issues of the autofix linters and either pushes the fixes
+// to the head branch or opens a pull request against the head branch.
+// Only the fixes of lines that the patch added are kept, golangci-lint fixes whole files.
+// It returns a text for the review and the issues that were not fixed.
+func (runner *Runner) autofix(workDir, repoDir string, patch *diff.Diff, res *printers.JSONResult) (string, []result.Issue, error) {
+	linters := runner.autofixLinters(res)
+
+	files := make(map[string]struct{})
+	var fixable []result.Issue
+	for _, issue := range res.Issues {
+		if issue.Replacement != nil && linters[issue.FromLinter] {
+			files[issue.FilePath()] = struct{}{}
+			fixable = append(fixable, issue)
+		}
+	}
+	if len(files) == 0 {
+		runner.Options.Logger.Debug("no fixable issues")
+		return "", res.Issues, nil
+	}
+
+	if runner.meta.Head.FullName != runner.meta.Base.FullName {
+		runner.Options.Logger.Info("not fixing %d issues because pull request is from fork %s", len(fixable), runner.meta.Head.FullName)
+		text, err := execute(runner.templates.autofixFork, &AutofixData{PullRequest: runner.meta, Issues: fixable})
+		if err != nil {
+			return "", nil, err
+		}
+		return text, res.Issues, nil
+	}
+
+	originals := make(map[string][]byte, len(files))
+	for file := range files {
+		buf, err := ioutil.ReadFile(filepath.Join(repoDir, file))
+		if err != nil {
+			return "", nil, fmt.Errorf("unable to read %s: %w", file, err)
+		}
+		originals[file] = buf
+	}
+
+	if err := runner.runFixer(workDir, repoDir, linters); err != nil {
+		return "", nil, err
+	}
+
+	fixedLines := make(map[string][]result.Range, len(files))
+	for file, original := range originals {
+		ranges, err := keepPatchFixes(filepath.Join(repoDir, file), original, patch.File(file))
+		if err != nil {
+			return "", nil, err
+		}
+		fixedLines[file] = ranges
+	}
+
+	var fixed, remaining []result.Issue
+	for _, issue := range res.Issues {
+		if issue.Replacement != nil && linters[issue.FromLinter] && overlaps(fixedLines[issue.FilePath()], issue.GetLineRange()) {
+			fixed = append(fixed, issue)
+			continue
+		}
+		remaining = append(remaining, issue)
+	}
+	if len(fixed) == 0 {
+		runner.Options.Logger.Debug("golangci-lint did not fix any issues in the changed lines")
+		return "", res.Issues, nil
+	}
+
+	repo, err := git.PlainOpen(repoDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to open repository: %w", err)
+	}
+	hash, err := runner.commitFixes(repo, files)
+	if err != nil {
+		return "", nil, err
+	}
+	if hash.IsZero() {
+		runner.Options.Logger.Debug("golangci-lint did not change any files")
+		return "", res.Issues, nil
+	}
+
+	if runner.Options.DryRun {
+		runner.Options.Logger.Info("not pushing fixes %s because of dry run", hash.String())
+		return "", res.Issues, nil
+	}
+
+	// try to push to the head branch first, this fails if we have no write access or the branch is protected
+	data := &AutofixData{PullRequest: runner.meta, Issues: fixed, Commit: hash.String()}
+	err = runner.push(repo, fmt.Sprintf("refs/heads/%s:refs/heads/%s", runner.meta.Head.Ref, runner.meta.Head.Ref))
+	if err == nil {
+		text, err := execute(runner.templates.autofixPushed, data)
+		if err != nil {
+			return "", nil, err
+		}
+		return text, remaining, nil
+	}
+	runner.Options.Logger.Debug("unable to push fixes to %s, opening a pull request instead: %s", runner.meta.Head.Ref, err)
+
+	if data.Ref, err = runner.openFixPullRequest(repo, data); err != nil {
+		return "", nil, err
+	}
+	text, err := execute(runner.templates.autofixPullRequest, data)
+	if err != nil {
+		return "", nil, err
+	}
+	return text, remaining, nil
+}
+
+// keepPatchFixes reverts the changes of the fixer to the file at path (original is the content before the fixer ran)
+// that do not touch a line added by the patch (f, nil if the patch does not change the file).
+// It returns the ranges of original lines whose fixes were kept.
+func keepPatchFixes(path string, original []byte, f *diff.File) ([]result.Range, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read fixed %s: %w", path, err)
+	}
+	if bytes.Equal(buf, original) {
+		return nil, nil
+	}
+
+	before := strings.SplitAfter(string(original), "\n")
+	after := strings.SplitAfter(string(buf), "\n")
+	var sb strings.Builder
+	var kept []result.Range
+	for _, op := range difflib.NewMatcherWithJunk(before, after, false, nil).GetOpCodes() {
+		if op.Tag == 'e' {
+			sb.WriteString(strings.Join(before[op.I1:op.I2], ""))
+			continue
+		}
+		// lines are 1-based, an insertion touches the lines around it
+		r := result.Range{From: op.I1 + 1, To: op.I2}
+		if op.I1 == op.I2 {
+			r = result.Range{From: op.I1, To: op.I1 + 1}
+		}
+		if addedLine(f, r) {
+			sb.WriteString(strings.Join(after[op.J1:op.J2], ""))
+			kept = append(kept, r)
+			continue
+		}
+		sb.WriteString(strings.Join(before[op.I1:op.I2], ""))
+	}
+	if err := ioutil.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return nil, fmt.Errorf("unable to write fixed %s: %w", path, err)
+	}
+	return kept, nil
+}
+
+// addedLine returns true if one of the lines of r was added in f.
+func addedLine(f *diff.File, r result.Range) bool {
+	if f == nil {
+		return false
+	}
+	for i := r.From; i <= r.To; i++ {
+		if line, ok := f.LineAt(i); ok && line.Kind == diff.Added {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps returns true if r overlaps one of the ranges.
+func overlaps(ranges []result.Range, r result.Range) bool {
+	for _, other := range ranges {
+		if r.From <= other.To && other.From <= r.To {
+			return true
+		}
+	}
+	return false
+}
+
+// autofixLinters returns the autofix linters that are enabled in the current run.
+func (runner *Runner) autofixLinters(res *printers.JSONResult) map[string]bool {
+	linters := make(map[string]bool)
+	for _, name := range runner.Options.AutofixLinters {
+		linters[name] = true
+	}
+	if res.Report == nil || len(res.Report.Linters) == 0 {
+		return linters
+	}
+	for _, linter := range res.Report.Linters {
+		if !linter.Enabled {
+			delete(linters, linter.Name)
+		}
+	}
+	return linters
+}
+
+func (runner *Runner) runFixer(workDir, repoDir string, linters map[string]bool) error {
+	cfg := runner.Options.LinterConfig
+	cfg.Run.Config = filepath.Join(workDir, "golangci-lint-fix.json")
+	cfg.Linters = config.Linters{
+		DisableAll: true,
+	}
+	for name := range linters {
+		cfg.Linters.Enable = append(cfg.Linters.Enable, name)
+	}
+	sort.Strings(cfg.Linters.Enable)
+	cfg.Issues.NeedFix = true
+
+	if err := writeConfig(cfg.Run.Config, &cfg); err != nil {
+		return fmt.Errorf("unable to write fix config: %w", err)
+	}
+
+	if _, err := runner.execLinter(runner.Options.CacheDir, workDir, repoDir, "run", "--config="+cfg.Run.Config); err != nil {
+		return fmt.Errorf("unable to fix issues: %w", err)
+	}
+	return nil
+}
+
+// commitFixes commits the changes of the specified files, a zero hash is returned if none of the files changed.
+func (runner *Runner) commitFixes(repo *git.Repository, files map[string]struct{}) (plumbing.Hash, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to get worktree: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to get worktree status: %w", err)
+	}
+
+	changed := 0
+	// only commit files we had issues for, golangci-lint might have touched others
+	for file := range files {
+		s, ok := status[file]
+		if !ok || s.Worktree == git.Unmodified {
+			continue
+		}
+		runner.Options.Logger.Debug("adding fixed file %s", file)
+		if _, err := worktree.Add(file); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("unable to add %s: %w", file, err)
+		}
+		changed++
+	}
+	if changed == 0 {
+		return plumbing.ZeroHash, nil
+	}
+
+	hash, err := worktree.Commit(autofixCommitMessage, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  autofixAuthorName,
+			Email: autofixAuthorEmail,
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to commit fixes: %w", err)
+	}
+	return hash, nil
+}
+
+// resetWorkTree resets the files of the clone to the head commit, the issues refer to its lines.
+func (runner *Runner) resetWorkTree(repoDir string) error {
+	repo, err := git.PlainOpen(repoDir)
+	if err != nil {
+		return fmt.Errorf("unable to open repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("unable to get worktree: %w", err)
+	}
+	err = worktree.Reset(&git.ResetOptions{Commit: plumbing.NewHash(runner.meta.Head.SHA), Mode: git.HardReset})
+	if err != nil {
+		return fmt.Errorf("unable to reset worktree to %s: %w", runner.meta.Head.SHA, err)
+	}
+	return nil
+}
+
+func (runner *Runner) push(repo *git.Repository, refSpec string) error {
+	runner.Options.Logger.Debug("pushing %s", refSpec)
+	return repo.PushContext(runner.Options.Context, &git.PushOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []gitConfig.RefSpec{gitConfig.RefSpec(refSpec)},
+		Auth:       runner.provider.Auth(),
+	})
+}
+
+// openFixPullRequest pushes the fixes to a separate branch and opens a pull request against the head branch.
+// If there is already an open pull request for the branch it is reused. It returns a reference to the pull request.
+func (runner *Runner) openFixPullRequest(repo *git.Repository, data *AutofixData) (string, error) {
+	title, err := execute(runner.templates.fixPullRequestTitle, data)
+	if err != nil {
+		return "", err
+	}
+	body, err := execute(runner.templates.fixPullRequestBody, data)
+	if err != nil {
+		return "", err
+	}
+
+	branch := fmt.Sprintf("golangci-lint-runner/fix-%d", runner.meta.PullRequestNumber)
+	if err := runner.push(repo, fmt.Sprintf("+refs/heads/%s:refs/heads/%s", runner.meta.Head.Ref, branch)); err != nil {
+		return "", fmt.Errorf("unable to push fixes to %s: %w", branch, err)
+	}
+
+	return runner.provider.CreateFixPullRequest(runner.Options.Context, branch, title, body)
+}
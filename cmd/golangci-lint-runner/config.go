@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/spf13/viper"
+	golangci_lint_runner "github.com/talon-one/golangci-lint-runner"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+const runnerConfigEnvar = "RUNNER_CONFIG"
+
+var (
+	// the file is read before the flags are parsed (see runnerConfigPath), the flag is for the help and validation
+	runnerConfigFlag  = kingpin.Flag("runner-config", "yaml file with the settings of the runner (keys are the flag names), flags and environment variables override its values").Envar(runnerConfigEnvar).ExistingFile()
+	defaultConfigFlag = kingpin.Flag("default-config", "golangci-lint config file that replaces the built-in default config").Envar("DEFAULT_CONFIG").ExistingFile()
+)
+
+// runnerConfig are the sections of the runner config file that are no flags.
+type runnerConfig struct {
+	// Templates are overridden by the --templates file and the text flags
+	Templates golangci_lint_runner.Templates
+	// LinterConfig replaces the built-in default config, it is nil if the section is not present
+	LinterConfig *config.Config
+}
+
+// fileConfig is the runner config file read in main.
+var fileConfig runnerConfig
+
+// runnerConfigPath returns the path of the runner config file from the arguments or the environment.
+func runnerConfigPath(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return os.Getenv(runnerConfigEnvar)
+		case strings.HasPrefix(arg, "--runner-config="):
+			return strings.TrimPrefix(arg, "--runner-config=")
+		case arg == "--runner-config" && i+1 < len(args):
+			return args[i+1]
+		}
+	}
+	return os.Getenv(runnerConfigEnvar)
+}
+
+// readRunnerConfigFile reads the runner config file path, see readRunnerConfig.
+func readRunnerConfigFile(app *kingpin.Application, path string) (runnerConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return runnerConfig{}, fmt.Errorf("unable to open runner config: %w", err)
+	}
+	defer f.Close()
+	cfg, err := readRunnerConfig(app, f)
+	if err != nil {
+		return runnerConfig{}, fmt.Errorf("unable to read runner config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// readRunnerConfig sets the values of the runner config as defaults of the matching flags of app and returns the other sections.
+// Settings of a command are in a section named like the command, e.g. app.queue-size.
+func readRunnerConfig(app *kingpin.Application, r io.Reader) (runnerConfig, error) {
+	var cfg runnerConfig
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(r); err != nil {
+		return cfg, err
+	}
+
+	settings := v.AllSettings()
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := settings[key]
+		switch key {
+		case "templates":
+			if err := v.UnmarshalKey(key, &cfg.Templates); err != nil {
+				return cfg, fmt.Errorf("unable to read %s: %w", key, err)
+			}
+		case "linter-config":
+			var linterConfig config.Config
+			sub := v.Sub(key)
+			if sub == nil {
+				return cfg, fmt.Errorf("%s must be a map", key)
+			}
+			if err := sub.Unmarshal(&linterConfig); err != nil {
+				return cfg, fmt.Errorf("unable to read %s: %w", key, err)
+			}
+			cfg.LinterConfig = &linterConfig
+		default:
+			if cmd := app.GetCommand(key); cmd != nil {
+				cmdSettings, ok := value.(map[string]interface{})
+				if !ok {
+					return cfg, fmt.Errorf("%s must be a map", key)
+				}
+				for name, value := range cmdSettings {
+					if err := setFlagDefault(cmd.GetFlag(name), key+"."+name, value); err != nil {
+						return cfg, err
+					}
+				}
+				continue
+			}
+			if err := setFlagDefault(app.GetFlag(key), key, value); err != nil {
+				return cfg, err
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// setFlagDefault sets value as default of flag, lists are joined with commas and maps become key=value pairs
+// (the keys of nested maps are joined with dots).
+func setFlagDefault(flag *kingpin.FlagClause, key string, value interface{}) error {
+	if flag == nil {
+		return fmt.Errorf("unknown setting %s", key)
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i := range v {
+			items[i] = fmt.Sprint(v[i])
+		}
+		flag.Default(strings.Join(items, ","))
+	case map[string]interface{}:
+		pairs := mapPairs("", v, nil)
+		sort.Strings(pairs)
+		flag.Default(pairs...)
+	case nil:
+	default:
+		flag.Default(fmt.Sprint(v))
+	}
+	return nil
+}
+
+// mapPairs appends the key=value pairs of m to pairs.
+func mapPairs(prefix string, m map[string]interface{}, pairs []string) []string {
+	for k, item := range m {
+		if nested, ok := item.(map[string]interface{}); ok {
+			pairs = mapPairs(prefix+k+".", nested, pairs)
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s%s=%v", prefix, k, item))
+	}
+	return pairs
+}
+
+// linterConfig returns the default golangci-lint config: the --default-config file, the linter-config section of
+// the runner config or the built-in default.
+func linterConfig(logger golangci_lint_runner.Logger) config.Config {
+	var cfg config.Config
+	var err error
+	switch {
+	case *defaultConfigFlag != "":
+		var f *os.File
+		f, err = os.Open(*defaultConfigFlag)
+		if err != nil {
+			logger.Error("could not open default config: %s", err)
+			os.Exit(1)
+		}
+		cfg, err = golangci_lint_runner.ReadLinterConfig(f)
+		f.Close()
+	case fileConfig.LinterConfig != nil:
+		cfg = *fileConfig.LinterConfig
+	default:
+		cfg, err = golangci_lint_runner.DefaultLinterConfig()
+	}
+	if err != nil {
+		logger.Error("could not read default config: %s", err)
+		os.Exit(1)
+	}
+	cfg.Run.Config = *configFileFlag
+	return cfg
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestReadRunnerConfig(t *testing.T) {
+	newApp := func() (*kingpin.Application, *int, *string, *bool, *map[string]string, *int) {
+		app := kingpin.New("test", "")
+		maxComments := app.Flag("max-comments", "").Envar("TEST_MAX_COMMENTS").Default("50").Int()
+		linters := app.Flag("autofix-linters", "").Default("gofmt").String()
+		approve := app.Flag("approve", "").Bool()
+		reports := app.Flag("report", "").StringMap()
+		cmd := app.Command("app", "")
+		queueSize := cmd.Flag("queue-size", "").Default("100").Int()
+		return app, maxComments, linters, approve, reports, queueSize
+	}
+	const file = `
+max-comments: 7
+autofix-linters: [gofmt, misspell]
+approve: true
+report:
+  sarif: out.sarif
+app:
+  queue-size: 3
+templates:
+  no-issues: all good
+linter-config:
+  linters:
+    enable: [govet]
+`
+
+	t.Run("file values", func(t *testing.T) {
+		app, maxComments, linters, approve, reports, queueSize := newApp()
+		cfg, err := readRunnerConfig(app, strings.NewReader(file))
+		require.NoError(t, err)
+		_, err = app.Parse([]string{"app"})
+		require.NoError(t, err)
+		require.Equal(t, 7, *maxComments)
+		require.Equal(t, "gofmt,misspell", *linters)
+		require.True(t, *approve)
+		require.Equal(t, map[string]string{"sarif": "out.sarif"}, *reports)
+		require.Equal(t, 3, *queueSize)
+		require.Equal(t, "all good", cfg.Templates.NoIssues)
+		require.NotNil(t, cfg.LinterConfig)
+		require.Equal(t, []string{"govet"}, cfg.LinterConfig.Linters.Enable)
+	})
+
+	t.Run("flags and environment override the file", func(t *testing.T) {
+		setenv(t, "TEST_MAX_COMMENTS", "9")
+		app, maxComments, _, approve, _, queueSize := newApp()
+		_, err := readRunnerConfig(app, strings.NewReader(file))
+		require.NoError(t, err)
+		_, err = app.Parse([]string{"--no-approve", "app", "--queue-size=4"})
+		require.NoError(t, err)
+		require.Equal(t, 9, *maxComments)
+		require.False(t, *approve)
+		require.Equal(t, 4, *queueSize)
+	})
+
+	t.Run("nested map keys", func(t *testing.T) {
+		app := kingpin.New("test", "")
+		merge := app.Flag("merge", "").StringMap()
+		_, err := readRunnerConfig(app, strings.NewReader("merge:\n  linters.enable: append\n  run.skip-dirs: remove\n"))
+		require.NoError(t, err)
+		_, err = app.Parse(nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"linters.enable": "append", "run.skip-dirs": "remove"}, *merge)
+	})
+
+	t.Run("unknown setting", func(t *testing.T) {
+		app, _, _, _, _, _ := newApp()
+		_, err := readRunnerConfig(app, strings.NewReader("app:\n  unknown: 1\n"))
+		require.EqualError(t, err, "unknown setting app.unknown")
+	})
+}
+
+// TestNoRequiredFlags checks that all flags can be set in the runner config file,
+// kingpin rejects defaults of required flags.
+func TestNoRequiredFlags(t *testing.T) {
+	model := kingpin.CommandLine.Model()
+	flags := model.Flags
+	for _, cmd := range model.Commands {
+		flags = append(flags, cmd.Flags...)
+	}
+	for _, flag := range flags {
+		require.False(t, flag.Required, flag.Name)
+	}
+}
+
+func TestRunnerConfigPath(t *testing.T) {
+	setenv(t, runnerConfigEnvar, "env.yml")
+	require.Equal(t, "a.yml", runnerConfigPath([]string{"--debug", "--runner-config", "a.yml", "app"}))
+	require.Equal(t, "b.yml", runnerConfigPath([]string{"app", "--runner-config=b.yml"}))
+	require.Equal(t, "env.yml", runnerConfigPath([]string{"app", "--", "--runner-config=c.yml"}))
+}
+
+// setenv sets the environment variable for the test, t.Setenv needs go 1.17.
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	require.NoError(t, os.Setenv(key, value))
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, old)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
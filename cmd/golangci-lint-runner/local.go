@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+	golangci_lint_runner "github.com/talon-one/golangci-lint-runner"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	localCmd        = kingpin.Command("local", "lint the committed changes of a local git checkout like a pull request, without github (the org config is not applied)")
+	localBaseFlag   = localCmd.Flag("base", "the revision the changes are compared to (the base branch of the pull request)").Default("origin/main").String()
+	localDirFlag    = localCmd.Flag("dir", "the git checkout").Default(".").ExistingDir()
+	localFormatFlag = localCmd.Flag("format", "output format").Default("text").Enum("text", "json")
+)
+
+func local() {
+	logger := newLogger()
+	logger.Debug("running in local mode")
+
+	// keep the cache between runs
+	if *cacheDirFlag == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			logger.Error("could not get cache dir: %s", err)
+			os.Exit(1)
+		}
+		*cacheDirFlag = filepath.Join(dir, "golangci-lint-runner")
+		if err := os.MkdirAll(*cacheDirFlag, 0700); err != nil {
+			logger.Error("could not create cache dir: %s", err)
+			os.Exit(1)
+		}
+	}
+
+	res, err := golangci_lint_runner.Local(*options(logger), *localDirFlag, *localBaseFlag)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if *localFormatFlag == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			logger.Error("could not encode result: %s", err)
+			os.Exit(1)
+		}
+	} else {
+		printLocalResult(res)
+	}
+
+	if res.HasErrors() {
+		os.Exit(1)
+	}
+}
+
+func printLocalResult(res *golangci_lint_runner.LocalResult) {
+	printIssues := func(issues []result.Issue) {
+		for i := range issues {
+			issue := &issues[i]
+			var severity string
+			if issue.Severity != "" && issue.Severity != golangci_lint_runner.SeverityError {
+				severity = issue.Severity + ": "
+			}
+			fmt.Printf("%s: %s%s (%s)\n", issue.Pos, severity, issue.Text, issue.FromLinter)
+		}
+	}
+
+	printIssues(res.Issues)
+	if len(res.OtherFiles) > 0 {
+		fmt.Printf("\n%d issues in unchanged files:\n", len(res.OtherFiles))
+		printIssues(res.OtherFiles)
+	}
+	if len(res.SameFile) > 0 {
+		fmt.Printf("\n%d issues outside of the changed lines:\n", len(res.SameFile))
+		printIssues(res.SameFile)
+	}
+	for _, w := range res.Warnings {
+		fmt.Printf("\nwarning: %s: %s\n", w.Tag, w.Text)
+	}
+}
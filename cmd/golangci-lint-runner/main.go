@@ -16,8 +16,7 @@ import (
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
-	"github.com/golangci/golangci-lint/pkg/config"
-	"github.com/google/go-github/github"
+	"github.com/imdario/mergo"
 	golangci_lint_runner "github.com/talon-one/golangci-lint-runner"
 	"github.com/valyala/fastjson"
 	"golang.org/x/oauth2"
@@ -25,29 +24,54 @@ import (
 )
 
 var (
-	cacheDirFlag        = kingpin.Flag("cache-dir", "cache dir").Envar("CACHE_DIR").String()
-	approveFlag         = kingpin.Flag("approve", "whether the app should approve if no issues were found (selecting false will only result in a comment)").Envar("APPROVE").Bool()
-	requestChangesFlag  = kingpin.Flag("request-changes", "whether the bot should request changes if issues were found (selecting false will only result in a comment)").Envar("REQUEST_CHANGES").Bool()
-	noChangesTextFlag   = kingpin.Flag("no-changes-text", "the text the bot should send if there are no go code changes").Envar("NO_CHANGES_TEXT").Default().String()
-	noIssuesTextFlag    = kingpin.Flag("no-issues-text", "the text the bot should send if there are no issues").Envar("NO_ISSUES_TEXT").Default("").String()
-	noNewIssuesTextFlag = kingpin.Flag("no-new-issues-text", "the text the bot should send if there are no new issues").Envar("NO_NEW_ISSUES_TEXT").Default("").String()
-	configFileFlag      = kingpin.Flag("config", "which config file to use").Envar("CONFIG_FILE").Default(".golangci.yml").String()
-	debugFlag           = kingpin.Flag("debug", "enable debug log").Envar("DEBUG").Hidden().Bool()
-	dryRunFlag          = kingpin.Flag("dry-run", "do not actual post on the pr").Envar("DRY_RUN").Bool()
+	cacheDirFlag           = kingpin.Flag("cache-dir", "cache dir").Envar("CACHE_DIR").String()
+	approveFlag            = kingpin.Flag("approve", "whether the app should approve if no issues were found (selecting false will only result in a comment)").Envar("APPROVE").Bool()
+	requestChangesFlag     = kingpin.Flag("request-changes", "whether the bot should request changes if issues were found (selecting false will only result in a comment)").Envar("REQUEST_CHANGES").Bool()
+	noChangesTextFlag      = kingpin.Flag("no-changes-text", "the text (template) the bot should send if there are no go code changes").Envar("NO_CHANGES_TEXT").Default().String()
+	noIssuesTextFlag       = kingpin.Flag("no-issues-text", "the text (template) the bot should send if there are no issues").Envar("NO_ISSUES_TEXT").Default("").String()
+	noNewIssuesTextFlag    = kingpin.Flag("no-new-issues-text", "the text (template) the bot should send if there are no new issues").Envar("NO_NEW_ISSUES_TEXT").Default("").String()
+	issuesTextFlag         = kingpin.Flag("issues-text", "the text (template) the bot should send if there are new issues").Envar("ISSUES_TEXT").Default("").String()
+	maxCommentsFlag        = kingpin.Flag("max-comments", "maximum number of inline comments per review, remaining issues are listed in the summary (0 is unlimited)").Envar("MAX_COMMENTS").Default("50").Int()
+	maxCommentsPerFileFlag = kingpin.Flag("max-comments-per-file", "maximum number of inline comments per file and review (0 is unlimited)").Envar("MAX_COMMENTS_PER_FILE").Default("10").Int()
+	templatesFileFlag      = kingpin.Flag("templates", "yaml file with templates for the messages the bot sends").Envar("TEMPLATES_FILE").ExistingFile()
+	configFileFlag         = kingpin.Flag("config", "which config file to use").Envar("CONFIG_FILE").Default(".golangci.yml").String()
+	debugFlag              = kingpin.Flag("debug", "enable debug log (same as --log-level=debug)").Envar("DEBUG").Hidden().Bool()
+	logFormatFlag          = kingpin.Flag("log-format", "log format").Envar("LOG_FORMAT").Default(golangci_lint_runner.LogFormatText).Enum(golangci_lint_runner.LogFormats()...)
+	logLevelFlag           = kingpin.Flag("log-level", "minimum level of logged messages").Envar("LOG_LEVEL").Default("info").Enum(golangci_lint_runner.LogLevels()...)
+	dryRunFlag             = kingpin.Flag("dry-run", "do not actual post on the pr").Envar("DRY_RUN").Bool()
+	autofixFlag            = kingpin.Flag("autofix", "fix issues of the autofix linters by pushing a commit to the head branch (or opening a pull request against it) instead of commenting").Envar("AUTOFIX").Bool()
+	outdatedCommentsFlag   = kingpin.Flag("outdated-comments", "what to do with comments of previous runs whose issues are no longer reported").Envar("OUTDATED_COMMENTS").Default(golangci_lint_runner.OutdatedCommentsResolve).Enum(golangci_lint_runner.OutdatedCommentsKeep, golangci_lint_runner.OutdatedCommentsResolve, golangci_lint_runner.OutdatedCommentsMinimize, golangci_lint_runner.OutdatedCommentsReply)
+	githubURLFlag          = kingpin.Flag("github-url", "api url of GitHub Enterprise Server (e.g. https://github.example.com/api/v3), defaults to github.com").Envar("GITHUB_API_URL").String()
+	orgConfigFlag          = kingpin.Flag("org-config", "location (owner/repo/path) of a shared golangci-lint config that is merged before the config of the repository, {owner} is replaced with the owner of the repository, e.g. {owner}/.github/golangci.yml").Envar("ORG_CONFIG").String()
+	configSourceFlag       = kingpin.Flag("config-source", "commit the config of the repository is read from, base prevents pull requests from changing the config they are linted with").Envar("CONFIG_SOURCE").Default(golangci_lint_runner.ConfigSourceHead).Enum(golangci_lint_runner.ConfigSourceHead, golangci_lint_runner.ConfigSourceBase)
+	configPolicyFlag       = kingpin.Flag("config-policy", "comma separated settings (or sections) the config of a repository may set, a leading ! forbids a setting and * matches all settings, the most specific entry wins").Envar("CONFIG_POLICY").Default(strings.Join(golangci_lint_runner.DefaultConfigPolicy, ",")).String()
+	rejectForbiddenFlag    = kingpin.Flag("reject-forbidden-settings", "fail (and report the config as invalid) if the config of a repository has forbidden settings instead of ignoring them").Envar("REJECT_FORBIDDEN_SETTINGS").Bool()
+	linterDirFlag          = kingpin.Flag("linter-dir", "directory with installed golangci-lint versions as <version>/golangci-lint, repositories can require one of them").Envar("LINTER_DIR").ExistingDir()
+	mergeFlag              = kingpin.Flag("merge", fmt.Sprintf("merge strategy of a setting of the golangci-lint config when the org and repository configs are merged, setting=strategy (strategies: %s), can be repeated, e.g. linters.enable=append", strings.Join(golangci_lint_runner.MergeStrategies(), ", "))).Envar("MERGE").StringMap()
+	statusFlag             = kingpin.Flag("status", "set a commit status on the head commit").Envar("STATUS").Bool()
+	autofixLintersFlag     = kingpin.Flag("autofix-linters", "comma separated list of linters whose issues should be fixed in autofix mode").Envar("AUTOFIX_LINTERS").Default(strings.Join(golangci_lint_runner.DefaultAutofixLinters, ",")).String()
 
 	appCmd            = kingpin.Command("app", "run as an app")
-	addrFlag          = appCmd.Flag("host-addr", "address to listen to, if unspecified takes HOST_ADDR environment variable").Envar("HOST_ADDR").Required().String()
-	privateKeyFlag    = appCmd.Flag("private-key", "github private key").Envar("GITHUB_PRIVATE_KEY").Required().ExistingFile()
-	webhookSecretFlag = appCmd.Flag("webhook-secret", "github webhook secret").Envar("GITHUB_WEBHOOK_SECRET").Required().String()
-	appIdFlag         = appCmd.Flag("appid", "github app id").Envar("GITHUB_APP_ID").Required().Int64()
+	addrFlag          = appCmd.Flag("host-addr", "address to listen to, if unspecified takes HOST_ADDR environment variable").Envar("HOST_ADDR").String()
+	privateKeyFlag    = appCmd.Flag("private-key", "github private key").Envar("GITHUB_PRIVATE_KEY").ExistingFile()
+	webhookSecretFlag = appCmd.Flag("webhook-secret", "github webhook secret").Envar("GITHUB_WEBHOOK_SECRET").String()
+	appIdFlag         = appCmd.Flag("appid", "github app id").Envar("GITHUB_APP_ID").Int64()
 	queueSizeFlag     = appCmd.Flag("queue-size", "queue size").Envar("QUEUE_SIZE").Default("100").Int()
 
+	gitLabURLFlag           = appCmd.Flag("gitlab-url", "gitlab url").Envar("GITLAB_URL").Default(golangci_lint_runner.DefaultGitLabURL).String()
+	gitLabTokenFlag         = appCmd.Flag("gitlab-token", "gitlab access token, enables gitlab merge requests (webhook path /gitlab)").Envar("GITLAB_TOKEN").String()
+	gitLabWebhookSecretFlag = appCmd.Flag("gitlab-webhook-secret", "gitlab webhook secret token").Envar("GITLAB_WEBHOOK_SECRET").String()
+	giteaURLFlag            = appCmd.Flag("gitea-url", "gitea (or forgejo) url, enables gitea pull requests (webhook path /gitea)").Envar("GITEA_URL").String()
+	giteaTokenFlag          = appCmd.Flag("gitea-token", "gitea access token").Envar("GITEA_TOKEN").String()
+	giteaWebhookSecretFlag  = appCmd.Flag("gitea-webhook-secret", "gitea webhook secret").Envar("GITEA_WEBHOOK_SECRET").String()
+
 	standAloneCmd         = kingpin.Command("standalone", "run standalone")
-	tokenFlag             = standAloneCmd.Flag("token", "github token to use").Envar("GITHUB_TOKEN").Required().String()
+	tokenFlag             = standAloneCmd.Flag("token", "github token to use").Envar("GITHUB_TOKEN").String()
 	pullRequestNumberFlag = standAloneCmd.Flag("pull-request-number", "github pull request number").Envar("GITHUB_PULL_REQUEST_NUMBER").Int()
 	repoNameFlag          = standAloneCmd.Flag("repo-name", "github repository name").Envar("GITHUB_REPO_NAME").String()
 	repoOwnerFlag         = standAloneCmd.Flag("repo-owner", "github repository owner").Envar("GITHUB_REPO_OWNER").String()
 	repoRepoFlag          = standAloneCmd.Flag("repo", "github full repository").Envar("GITHUB_REPOSITORY").String()
+	reportFlag            = standAloneCmd.Flag("report", fmt.Sprintf("write the issues to a report file, format=path (formats: %s), can be repeated", strings.Join(golangci_lint_runner.ReportFormats(), ", "))).Envar("REPORT").StringMap()
 	eventPath             = standAloneCmd.Flag("event-path", "the event path (only github actions)").Envar("GITHUB_EVENT_PATH").String()
 )
 var version string
@@ -56,191 +80,46 @@ var date string
 
 func main() {
 	kingpin.Version(fmt.Sprintf("%s %s %s", version, commit, date))
+	if path := runnerConfigPath(os.Args[1:]); path != "" {
+		var err error
+		if fileConfig, err = readRunnerConfigFile(kingpin.CommandLine, path); err != nil {
+			log.Fatal(err)
+		}
+	}
 	switch kingpin.Parse() {
 	case appCmd.FullCommand():
 		server()
 	case standAloneCmd.FullCommand():
 		standalone()
+	case localCmd.FullCommand():
+		local()
 	}
 }
 
-func options(logger logger) *golangci_lint_runner.Options {
+func options(logger golangci_lint_runner.Logger) *golangci_lint_runner.Options {
 	var err error
 
-	config := config.Config{
-		Run: config.Run{
-			Config: *configFileFlag,
-		},
-		Output: struct {
-			Format              string
-			Color               string
-			PrintIssuedLine     bool   `mapstructure:"print-issued-lines"`
-			PrintLinterName     bool   `mapstructure:"print-linter-name"`
-			UniqByLine          bool   `mapstructure:"uniq-by-line"`
-			SortResults         bool   `mapstructure:"sort-results"`
-			PrintWelcomeMessage bool   `mapstructure:"print-welcome"`
-			PathPrefix          string `mapstructure:"path-prefix"`
-		}{
-			PrintLinterName: true,
-		},
-		LintersSettings: config.LintersSettings{
-			Errcheck: config.ErrcheckSettings{
-				CheckTypeAssertions: false,
-				CheckAssignToBlank:  false,
-			},
-			Funlen: struct {
-				Lines      int
-				Statements int
-			}{
-				Lines:      60,
-				Statements: 40,
-			},
-			Lll: config.LllSettings{
-				LineLength: 120,
-				TabWidth:   1,
-			},
-			Govet: config.GovetSettings{
-				CheckShadowing: false,
-				Settings:       nil,
-				Enable: []string{
-					"asmdecl",
-					"assign",
-					"atomic",
-					"bools",
-					"buildtag",
-					"cgocall",
-					"composites",
-					"copylocks",
-					"httpresponse",
-					"loopclosure",
-					"lostcancel",
-					"nilfunc",
-					"printf",
-					"shift",
-					"stdmethods",
-					"structtag",
-					"tests",
-					"unmarshal",
-					"unreachable",
-					"unsafeptr",
-					"unusedresult",
-				},
-				Disable:    []string{"unreachable"},
-				EnableAll:  false,
-				DisableAll: false,
-			},
-			Golint: struct {
-				MinConfidence float64 `mapstructure:"min-confidence"`
-			}{
-				MinConfidence: 0.8,
-			},
-			Gofmt: struct{ Simplify bool }{Simplify: true},
-			Gocyclo: struct {
-				MinComplexity int `mapstructure:"min-complexity"`
-			}{
-				MinComplexity: 20,
-			},
-			Unparam: config.UnparamSettings{
-				CheckExported: true,
-				Algo:          "cha",
-			},
-			Nakedret: config.NakedretSettings{
-				MaxFuncLines: 30,
-			},
-			Prealloc: config.PreallocSettings{
-				Simple:     true,
-				RangeLoops: true,
-				ForLoops:   false,
-			},
-			Gocritic: config.GocriticSettings{
-				EnabledChecks: []string{
-					"assignOp",
-					"captLocal",
-					"defaultCaseOrder",
-					"elseif",
-					"ifElseChain",
-					"regexpMust",
-					"singleCaseSwitch",
-					"sloppyLen",
-					"switchTrue",
-					"typeSwitchVar",
-					"underef",
-					"unlambda",
-					"unslice",
-				},
-				SettingsPerCheck: map[string]config.GocriticCheckSettings{},
-			},
-			Godox: config.GodoxSettings{
-				Keywords: []string{"TODO", "BUG", "FIXME"},
-			},
-			Dogsled: config.DogsledSettings{
-				MaxBlankIdentifiers: 2,
-			},
-			Gocognit: config.GocognitSettings{
-				MinComplexity: 20,
-			},
-			Maligned: struct {
-				SuggestNewOrder bool `mapstructure:"suggest-new"`
-			}{
-				SuggestNewOrder: true,
-			},
-			Dupl: struct{ Threshold int }{Threshold: 100},
-			Goconst: struct {
-				MinStringLen        int `mapstructure:"min-len"`
-				MinOccurrencesCount int `mapstructure:"min-occurrences"`
-			}{
-				MinStringLen:        3,
-				MinOccurrencesCount: 3,
-			},
-			Misspell: struct {
-				Locale      string
-				IgnoreWords []string `mapstructure:"ignore-words"`
-			}{
-				Locale:      "US",
-				IgnoreWords: nil,
-			},
-			Unused: struct {
-				CheckExported bool `mapstructure:"check-exported"`
-			}{
-				CheckExported: true,
-			},
-			Whitespace: struct {
-				MultiIf   bool `mapstructure:"multi-if"`
-				MultiFunc bool `mapstructure:"multi-func"`
-			}{
-				MultiIf:   false,
-				MultiFunc: false,
-			},
-			WSL: config.WSLSettings{
-				StrictAppend:                     true,
-				AllowAssignAndCallCuddle:         true,
-				AllowMultiLineAssignCuddle:       true,
-				AllowCuddleDeclaration:           false,
-				AllowTrailingComment:             false,
-				ForceCaseTrailingWhitespaceLimit: 0,
-			},
-		},
-		Linters: config.Linters{
-			Enable:     []string{"deadcode", "errcheck", "gocritic", "gocyclo", "goimports", "golint", "gosimple", "govet", "ineffassign", "misspell", "nakedret", "prealloc", "staticcheck", "structcheck", "typecheck", "unconvert", "unparam", "unused", "varcheck"},
-			Disable:    nil,
-			EnableAll:  false,
-			DisableAll: true,
-			Fast:       false,
-			Presets:    nil,
-		},
-	}
-
 	options := golangci_lint_runner.Options{
-		Logger:          logger,
-		Timeout:         0,
-		CacheDir:        *cacheDirFlag,
-		Approve:         *approveFlag,
-		RequestChanges:  *requestChangesFlag,
-		DryRun:          *dryRunFlag,
-		LinterConfig:    config,
-		NoChangesText:   *noChangesTextFlag,
-		NoIssuesText:    *noIssuesTextFlag,
-		NoNewIssuesText: *noNewIssuesTextFlag,
+		Logger:                  logger,
+		Timeout:                 0,
+		CacheDir:                *cacheDirFlag,
+		Approve:                 *approveFlag,
+		RequestChanges:          *requestChangesFlag,
+		DryRun:                  *dryRunFlag,
+		Autofix:                 *autofixFlag,
+		Status:                  *statusFlag,
+		OrgConfig:               *orgConfigFlag,
+		MergeStrategies:         *mergeFlag,
+		ConfigSource:            *configSourceFlag,
+		ConfigPolicy:            splitList(*configPolicyFlag),
+		RejectForbiddenSettings: *rejectForbiddenFlag,
+		LinterDir:               *linterDirFlag,
+		AutofixLinters:          splitList(*autofixLintersFlag),
+		OutdatedComments:        *outdatedCommentsFlag,
+		MaxComments:             *maxCommentsFlag,
+		MaxCommentsPerFile:      *maxCommentsPerFileFlag,
+		LinterConfig:            linterConfig(logger),
+		Templates:               templates(logger),
 	}
 
 	if options.Timeout <= 0 {
@@ -262,29 +141,52 @@ func options(logger logger) *golangci_lint_runner.Options {
 }
 
 func server() {
-	logger := logger{}
+	logger := newLogger()
 	logger.Debug("running in server mode")
-	// read private key
-	privateKeyBytes, err := ioutil.ReadFile(*privateKeyFlag)
-	if err != nil {
-		logger.Error("could not read private key: %s", err)
-		os.Exit(1)
-	}
-
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyBytes)
-	if err != nil {
-		logger.Error("could not parse private key: %s", err)
-		os.Exit(1)
-	}
-
 	options := golangci_lint_runner.ServerOptions{
-		PrivateKey:    privateKey,
 		WebhookSecret: *webhookSecretFlag,
 		AppID:         *appIdFlag,
+		GitHubURL:     *githubURLFlag,
 		QueueSize:     *queueSizeFlag,
 		Options:       options(logger),
 	}
 
+	if *privateKeyFlag != "" {
+		// read private key
+		privateKeyBytes, err := ioutil.ReadFile(*privateKeyFlag)
+		if err != nil {
+			logger.Error("could not read private key: %s", err)
+			os.Exit(1)
+		}
+
+		options.PrivateKey, err = jwt.ParseRSAPrivateKeyFromPEM(privateKeyBytes)
+		if err != nil {
+			logger.Error("could not parse private key: %s", err)
+			os.Exit(1)
+		}
+	}
+
+	if *gitLabTokenFlag != "" {
+		options.GitLab = &golangci_lint_runner.GitLabOptions{
+			URL:           *gitLabURLFlag,
+			Token:         *gitLabTokenFlag,
+			WebhookSecret: *gitLabWebhookSecretFlag,
+		}
+	}
+
+	if *giteaURLFlag != "" {
+		options.Gitea = &golangci_lint_runner.GiteaOptions{
+			URL:           *giteaURLFlag,
+			Token:         *giteaTokenFlag,
+			WebhookSecret: *giteaWebhookSecretFlag,
+		}
+	}
+
+	if *addrFlag == "" {
+		logger.Error("--host-addr must be specified")
+		os.Exit(1)
+	}
+
 	if options.QueueSize <= 0 {
 		logger.Error("could not use a queue <= 0")
 		os.Exit(1)
@@ -305,9 +207,15 @@ func server() {
 }
 
 func standalone() {
-	logger := logger{}
+	logger := newLogger()
 	logger.Debug("running in standalone mode")
 
+	// not a required flag, kingpin rejects defaults of required flags from the runner config file
+	if *tokenFlag == "" {
+		logger.Error("--token must be specified")
+		os.Exit(1)
+	}
+
 	if *repoNameFlag == "" && *repoOwnerFlag == "" && *repoRepoFlag == "" {
 		logger.Error("must either specify --repo or --repo-name + --repo-owner")
 		os.Exit(1)
@@ -345,15 +253,23 @@ func standalone() {
 	}
 
 	opt := options(logger)
-	opt.Context, _ = context.WithTimeout(context.Background(), opt.Timeout)
+	var cancel context.CancelFunc
+	opt.Context, cancel = context.WithTimeout(context.Background(), opt.Timeout)
+	defer cancel()
 	opt.PullRequestNumber = *pullRequestNumberFlag
 	opt.Owner = *repoOwnerFlag
 	opt.Name = *repoNameFlag
 	opt.CloneToken = *tokenFlag
+	opt.Reports = *reportFlag
 
-	opt.Client = github.NewClient(oauth2.NewClient(opt.Context, oauth2.StaticTokenSource(
+	var err error
+	opt.Client, err = golangci_lint_runner.NewGitHubClient(*githubURLFlag, oauth2.NewClient(opt.Context, oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: *tokenFlag},
 	)))
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
 
 	runner, err := golangci_lint_runner.NewRunner(*opt)
 	if err != nil {
@@ -367,23 +283,60 @@ func standalone() {
 	}
 }
 
-type logger struct{}
-
-func (logger) Debug(format string, a ...interface{}) {
-	if debugFlag == nil || !*debugFlag {
-		return
-	}
-	log.Println(fmt.Sprintf("[DEBUG] "+format, a...))
-}
-
-func (logger) Info(format string, a ...interface{}) {
-	log.Println(fmt.Sprintf(format, a...))
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
 }
 
-func (logger) Warn(format string, a ...interface{}) {
-	log.Println(fmt.Sprintf("Warning: "+format, a...))
+// newLogger creates the logger configured by --log-format and --log-level.
+func newLogger() golangci_lint_runner.Logger {
+	level := *logLevelFlag
+	if *debugFlag {
+		level = "debug"
+	}
+	logger, err := golangci_lint_runner.NewLogger(os.Stderr, *logFormatFlag, level)
+	if err != nil {
+		log.Fatalf("could not create logger: %s", err)
+	}
+	return logger
 }
 
-func (logger) Error(format string, a ...interface{}) {
-	log.Println(fmt.Sprintf("Error: "+format, a...))
+// templates applies the templates file and the text flags on top of the templates of the runner config.
+func templates(logger golangci_lint_runner.Logger) golangci_lint_runner.Templates {
+	t := fileConfig.Templates
+	if *templatesFileFlag != "" {
+		f, err := os.Open(*templatesFileFlag)
+		if err != nil {
+			logger.Error("could not open templates file: %s", err)
+			os.Exit(1)
+		}
+		fileTemplates, err := golangci_lint_runner.ReadTemplates(f)
+		f.Close()
+		if err != nil {
+			logger.Error("could not read templates file %s: %s", *templatesFileFlag, err)
+			os.Exit(1)
+		}
+		if err := mergo.Merge(&t, fileTemplates, mergo.WithOverride); err != nil {
+			logger.Error("could not merge templates file %s: %s", *templatesFileFlag, err)
+			os.Exit(1)
+		}
+	}
+	if *noChangesTextFlag != "" {
+		t.NoChanges = *noChangesTextFlag
+	}
+	if *noIssuesTextFlag != "" {
+		t.NoIssues = *noIssuesTextFlag
+	}
+	if *noNewIssuesTextFlag != "" {
+		t.NoNewIssues = *noNewIssuesTextFlag
+	}
+	if *issuesTextFlag != "" {
+		t.Issues = *issuesTextFlag
+	}
+	if err := t.Validate(); err != nil {
+		logger.Error("invalid templates: %s", err)
+		os.Exit(1)
+	}
+	return t
 }
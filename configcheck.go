@@ -0,0 +1,244 @@
+package golangci_lint_runner
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/google/go-github/github"
+	"github.com/talon-one/golangci-lint-runner/internal/diff"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigProblem is a problem of a config file, Line is 0 if it is not known.
+type ConfigProblem struct {
+	Line    int
+	Message string
+}
+
+// ConfigError is returned if a config file can not be read or contains values of the wrong type.
+type ConfigError struct {
+	File     string
+	Problems []ConfigProblem
+}
+
+func (e *ConfigError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.Message
+		if p.Line > 0 {
+			problems[i] = fmt.Sprintf("line %d: %s", p.Line, p.Message)
+		}
+	}
+	return fmt.Sprintf("invalid config %s: %s", e.File, strings.Join(problems, "; "))
+}
+
+// configError returns a *ConfigError for err, which has no line.
+func configError(file string, err error) error {
+	return &ConfigError{File: file, Problems: []ConfigProblem{{Message: err.Error()}}}
+}
+
+// configFile describes the settings of a config file.
+type configFile struct {
+	config.Config `mapstructure:",squash"`
+	// Service is the documented section for golangci.com, golangci-lint ignores it
+	Service map[string]interface{} `mapstructure:"service"`
+	Runner  struct {
+		Templates Templates              `mapstructure:"templates"`
+		Merge     map[string]interface{} `mapstructure:"merge"`
+		Version   string                 `mapstructure:"version"`
+	} `mapstructure:"golangci-lint-runner"`
+}
+
+var (
+	configFileType = reflect.TypeOf(configFile{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	yamlLineRegexp = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+)
+
+// checkConfig parses the config file content and returns the root node (nil for an empty file) and the unknown
+// settings, which golangci-lint ignores. Syntax errors and values of the wrong type are returned as *ConfigError.
+func checkConfig(file string, content []byte) (*yaml.Node, []ConfigProblem, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		problem := ConfigProblem{Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+		if m := yamlLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			problem.Line, _ = strconv.Atoi(m[1])
+			problem.Message = m[2]
+		}
+		return nil, nil, &ConfigError{File: file, Problems: []ConfigProblem{problem}}
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil, nil
+	}
+	var unknown []ConfigProblem
+	if problems := checkNode(doc.Content[0], configFileType, "", &unknown); len(problems) > 0 {
+		return nil, nil, &ConfigError{File: file, Problems: problems}
+	}
+	return doc.Content[0], unknown, nil
+}
+
+// checkNode checks that node can be decoded into a value of type t, path is the dot separated path of node.
+// Unknown settings are appended to unknown.
+func checkNode(node *yaml.Node, t reflect.Type, path string, unknown *[]ConfigProblem) []ConfigProblem {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	problem := func(format string, args ...interface{}) []ConfigProblem {
+		return []ConfigProblem{{Line: node.Line, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	var problems []ConfigProblem
+	switch t.Kind() {
+	case reflect.Interface:
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return problem("%s must be a map", path)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinPath(path, key.Value)
+			index, ok := fieldIndex(t, key.Value)
+			if !ok {
+				*unknown = append(*unknown, ConfigProblem{Line: key.Line, Message: fmt.Sprintf("unknown setting %s", keyPath)})
+				continue
+			}
+			problems = append(problems, checkNode(value, t.FieldByIndex(index).Type, keyPath, unknown)...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return problem("%s must be a map", path)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			problems = append(problems, checkNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), unknown)...)
+		}
+	case reflect.Slice:
+		switch {
+		case node.Kind == yaml.SequenceNode:
+			for _, item := range node.Content {
+				problems = append(problems, checkNode(item, t.Elem(), path, unknown)...)
+			}
+		// a comma separated string is split into a list
+		case node.Kind != yaml.ScalarNode || t.Elem().Kind() != reflect.String:
+			return problem("%s must be a list", path)
+		}
+	default:
+		if node.Kind != yaml.ScalarNode {
+			return problem("%s must be a value", path)
+		}
+		if !validScalar(node.Value, t) {
+			return problem("%s has an invalid value %q", path, node.Value)
+		}
+	}
+	return problems
+}
+
+// validScalar reports whether value can be decoded into a value of type t.
+func validScalar(value string, t reflect.Type) bool {
+	var err error
+	switch t.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(value) {
+		case "yes", "no", "on", "off", "y", "n":
+			return true
+		}
+		_, err = strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == durationType {
+			if _, err = time.ParseDuration(value); err == nil {
+				return true
+			}
+		}
+		_, err = strconv.ParseInt(value, 0, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(value, 0, 64)
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(value, 64)
+	}
+	return err == nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// InvalidConfigData is the data that is available in the InvalidConfig template.
+type InvalidConfigData struct {
+	PullRequest MetaData
+	Error       *ConfigError
+}
+
+// reportConfigError posts a review that explains the problems of the config file, problems on lines that are part
+// of the pull request are commented inline (if the config was read from the head commit).
+func (runner *Runner) reportConfigError(configErr *ConfigError) error {
+	runner.Options.Logger.Info("reporting %s", configErr)
+
+	body, err := execute(runner.templates.invalidConfig, &InvalidConfigData{PullRequest: runner.meta, Error: configErr})
+	if err != nil {
+		return err
+	}
+	reviewRequest := Review{
+		CommitID: github.String(runner.meta.Head.SHA),
+		Body:     github.String(body),
+		Event:    github.String(ReviewEventComment),
+	}
+	if runner.Options.RequestChanges {
+		reviewRequest.Event = github.String(ReviewEventRequestChanges)
+	}
+
+	var issues []result.Issue
+	for _, problem := range configErr.Problems {
+		if problem.Line > 0 {
+			issues = append(issues, result.Issue{
+				FromLinter: "golangci-lint-runner",
+				Text:       problem.Message,
+				Pos:        token.Position{Filename: configErr.File, Line: problem.Line},
+			})
+		}
+	}
+	// the lines of the base config do not belong to the patch
+	if runner.configSource() == ConfigSourceBase {
+		issues = nil
+	}
+	if len(issues) > 0 {
+		buf, err := runner.provider.Diff(runner.Options.Context)
+		if err != nil {
+			return fmt.Errorf("unable to download patch: %w", err)
+		}
+		patch, err := diff.Parse(bytes.NewReader(buf))
+		if err != nil {
+			return fmt.Errorf("unable to parse patch: %w", err)
+		}
+		// config problems have no source lines, the message identifies them
+		fingerprints := issueFingerprints("", issues)
+		for i := range issues {
+			if comment := makeComment(patch, &issues[i], issues[i].Text, fingerprints[i]); comment != nil {
+				reviewRequest.Comments = append(reviewRequest.Comments, comment)
+			}
+		}
+		if err := runner.filterComments(&reviewRequest); err != nil {
+			return fmt.Errorf("unable to filter comments: %w", err)
+		}
+	}
+
+	if err := runner.sendReview(&reviewRequest); err != nil {
+		return fmt.Errorf("unable to send review: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package golangci_lint_runner
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		problems []ConfigProblem
+		unknown  []ConfigProblem
+	}{
+		{
+			name:    "valid",
+			content: "run:\n  timeout: 5m\n  skip-dirs: vendor,gen\n  tests: yes\nlinters:\n  enable: [errcheck]\nlinters-settings:\n  lll:\n    line-length: 100\n  gocritic:\n    settings:\n      hugeparam:\n        sizethreshold: 80\nissues:\n  exclude-rules:\n    - linters: [lll]\n      path: _test\\.go\ngolangci-lint-runner:\n  merge:\n    linters.enable: append\n  templates:\n    no-issues: all good\n",
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:     "syntax error",
+			content:  "linters:\n  enable: [errcheck]\n\tdisable: [lll]\n",
+			problems: []ConfigProblem{{Line: 3, Message: "found character that cannot start any token"}},
+		},
+		{
+			name:    "unknown settings",
+			content: "linter:\n  enable: [errcheck]\nlinters-settings:\n  lll:\n    length: 100\nissues:\n  exclude-rules:\n    - linter: [lll]\ngolangci-lint-runner:\n  templates:\n    no-issue: all good\n",
+			unknown: []ConfigProblem{
+				{Line: 1, Message: "unknown setting linter"},
+				{Line: 5, Message: "unknown setting linters-settings.lll.length"},
+				{Line: 8, Message: "unknown setting issues.exclude-rules.linter"},
+				{Line: 11, Message: "unknown setting golangci-lint-runner.templates.no-issue"},
+			},
+		},
+		{
+			name:    "service section",
+			content: "service:\n  golangci-lint-version: 1.30.x\n  prepare:\n    - make deps\n",
+		},
+		{
+			name:    "wrong types",
+			content: "run:\n  timeout: soon\nlinters: [errcheck]\nlinters-settings:\n  lll:\n    line-length: [100]\nissues:\n  exclude-rules: lll\n",
+			problems: []ConfigProblem{
+				{Line: 2, Message: `run.timeout has an invalid value "soon"`},
+				{Line: 3, Message: "linters must be a map"},
+				{Line: 6, Message: "linters-settings.lll.line-length must be a value"},
+				{Line: 8, Message: "issues.exclude-rules must be a list"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, unknown, err := checkConfig(".golangci.yml", []byte(tt.content))
+			if tt.problems == nil {
+				require.NoError(t, err)
+				require.Equal(t, tt.unknown, unknown)
+				return
+			}
+			require.Equal(t, &ConfigError{File: ".golangci.yml", Problems: tt.problems}, err)
+		})
+	}
+}
+
+// TestCheckConfig_repository checks the config of this repository, which golangci-lint accepts.
+func TestCheckConfig_repository(t *testing.T) {
+	content, err := ioutil.ReadFile(".golangci.yml")
+	require.NoError(t, err)
+	_, unknown, err := checkConfig(".golangci.yml", content)
+	require.NoError(t, err)
+	// the setting is named packages-with-error-message in golangci-lint 1.30
+	require.Len(t, unknown, 1)
+	require.Equal(t, "unknown setting linters-settings.depguard.packages-with-error-messages", unknown[0].Message)
+}
+
+func TestConfigError_Error(t *testing.T) {
+	err := &ConfigError{File: ".golangci.yml", Problems: []ConfigProblem{{Line: 1, Message: "unknown setting linter"}, {Message: "invalid"}}}
+	require.EqualError(t, err, "invalid config .golangci.yml: line 1: unknown setting linter; invalid")
+}
@@ -0,0 +1,37 @@
+package golangci_lint_runner
+
+import (
+	"bytes"
+	_ "embed" // for the default config
+	"fmt"
+	"io"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/spf13/viper"
+)
+
+//go:embed default.golangci.yml
+var defaultLinterConfig []byte
+
+// DefaultLinterConfig returns the built-in golangci-lint config (default.golangci.yml).
+func DefaultLinterConfig() (config.Config, error) {
+	cfg, err := ReadLinterConfig(bytes.NewReader(defaultLinterConfig))
+	if err != nil {
+		return cfg, fmt.Errorf("unable to read default config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ReadLinterConfig reads a golangci-lint config from a yaml file.
+func ReadLinterConfig(r io.Reader) (config.Config, error) {
+	var cfg config.Config
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(r); err != nil {
+		return cfg, err
+	}
+	if err := v.Unmarshal(&cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
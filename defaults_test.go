@@ -0,0 +1,28 @@
+package golangci_lint_runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLinterConfig(t *testing.T) {
+	cfg, err := DefaultLinterConfig()
+	require.NoError(t, err)
+	require.True(t, cfg.Linters.DisableAll)
+	require.Contains(t, cfg.Linters.Enable, "govet")
+	require.Equal(t, 120, cfg.LintersSettings.Lll.LineLength)
+	require.Equal(t, 0.8, cfg.LintersSettings.Golint.MinConfidence)
+	require.True(t, cfg.Output.PrintLinterName)
+}
+
+func TestReadLinterConfig(t *testing.T) {
+	cfg, err := ReadLinterConfig(strings.NewReader("linters:\n  enable: [errcheck]\nrun:\n  skip-dirs: [gen]\n"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"errcheck"}, cfg.Linters.Enable)
+	require.Equal(t, []string{"gen"}, cfg.Run.SkipDirs)
+
+	_, err = ReadLinterConfig(strings.NewReader("linters: ["))
+	require.Error(t, err)
+}
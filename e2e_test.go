@@ -0,0 +1,495 @@
+package golangci_lint_runner
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/golangci/golangci-lint/pkg/printers"
+	"github.com/golangci/golangci-lint/pkg/report"
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+const (
+	e2eBase = "package main\n\nfunc main() {}\n"
+	e2eHead = "package main\n\nimport \"os\"\n\nfunc main() {\n\tos.Remove(\"file\")\n}\n"
+)
+
+var e2eIssue = result.Issue{
+	FromLinter:  "errcheck",
+	Text:        "Error return value of `os.Remove` is not checked",
+	SourceLines: []string{"\tos.Remove(\"file\")"},
+	Pos:         token.Position{Filename: "main.go", Line: 6},
+}
+
+// e2eFingerprint returns the fingerprint of e2eIssue in e2eHead.
+func e2eFingerprint(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(e2eHead), 0600))
+	return issueFingerprints(dir, []result.Issue{e2eIssue})[0]
+}
+
+// TestEndToEnd sends a pull request webhook and runs the queued job against the fake GitHub,
+// a fixture repository and the stub golangci-lint.
+func TestEndToEnd(t *testing.T) {
+	tests := []struct {
+		name string
+		head map[string]string
+		// configSource of the runner and the files of the base commit the fake GitHub serves
+		configSource string
+		baseFiles    map[string]string
+		// issues the stub golangci-lint reports, e2eIssue if nil
+		issues []result.Issue
+		// existing comments of the pull request
+		comments []*ReviewComment
+		event    string
+		// path, text and lines of the inline comments
+		path     string
+		text     string
+		lines    []int
+		statuses []string
+		// body contains the text
+		body    string
+		linted  bool
+		wantErr bool
+	}{
+		{
+			name:     "new issue",
+			head:     map[string]string{"main.go": e2eHead},
+			event:    ReviewEventRequestChanges,
+			path:     "main.go",
+			text:     e2eIssue.Text,
+			lines:    []int{6},
+			statuses: []string{StatusPending, StatusFailure},
+			linted:   true,
+		},
+		{
+			name:     "invalid config",
+			head:     map[string]string{"main.go": e2eHead, ".golangci.yml": "run:\n  timeout: soon\n"},
+			event:    ReviewEventRequestChanges,
+			path:     ".golangci.yml",
+			text:     `run.timeout has an invalid value "soon"`,
+			lines:    []int{2},
+			statuses: []string{StatusPending, StatusFailure},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown setting",
+			head:     map[string]string{"main.go": e2eHead, ".golangci.yml": "linters:\n  enabled: [errcheck]\n"},
+			event:    ReviewEventRequestChanges,
+			path:     "main.go",
+			text:     e2eIssue.Text,
+			lines:    []int{6},
+			statuses: []string{StatusPending, StatusFailure},
+			body:     "These settings of the lint config are unknown and were ignored: linters.enabled (line 2)",
+			linted:   true,
+		},
+		{
+			name:         "base config",
+			head:         map[string]string{"main.go": e2eHead, ".golangci.yml": "linters:\n  enabled: [errcheck]\n"},
+			configSource: ConfigSourceBase,
+			event:        ReviewEventRequestChanges,
+			path:         "main.go",
+			text:         e2eIssue.Text,
+			lines:        []int{6},
+			statuses:     []string{StatusPending, StatusFailure},
+			body:         "This pull request changes the lint config `.golangci.yml`, the config of the base branch was used.",
+			linted:       true,
+		},
+		{
+			name:         "invalid base config",
+			head:         map[string]string{"main.go": e2eHead, ".golangci.yml": "linters:\n  enable: [errcheck]\n"},
+			configSource: ConfigSourceBase,
+			baseFiles:    map[string]string{".golangci.yml": "run:\n  timeout: soon\n"},
+			event:        ReviewEventRequestChanges,
+			statuses:     []string{StatusPending, StatusFailure},
+			body:         `- line 2: run.timeout has an invalid value "soon"`,
+			wantErr:      true,
+		},
+		{
+			name: "already commented",
+			head: map[string]string{"main.go": e2eHead},
+			comments: []*ReviewComment{{
+				Path: github.String("main.go"),
+				Line: github.Int(6),
+				Body: github.String("errcheck\n\n" + fingerprintMarker(e2eFingerprint(t))),
+			}},
+			event:    ReviewEventApprove,
+			statuses: []string{StatusPending, StatusSuccess},
+			linted:   true,
+		},
+		{
+			name:     "typecheck outside of the changed lines",
+			head:     map[string]string{"main.go": e2eHead},
+			issues:   []result.Issue{{FromLinter: "typecheck", Text: "undeclared name: Foo", Pos: token.Position{Filename: "main.go", Line: 1}}},
+			event:    ReviewEventComment,
+			statuses: []string{StatusPending, StatusSuccess},
+			body:     "1 issues outside of the changed lines",
+			linted:   true,
+		},
+		{
+			name:     "no go code",
+			head:     map[string]string{"README.md": "# repo\n"},
+			event:    ReviewEventApprove,
+			statuses: []string{StatusPending, StatusSuccess},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			fixture := newFixtureRepo(t, root, "repo", map[string]string{"main.go": e2eBase}, tt.head)
+			gitServer := newGitServer(t, root, harnessInstallationToken)
+			issues := tt.issues
+			if issues == nil {
+				issues = []result.Issue{e2eIssue}
+			}
+			calls := installStubLinter(t, &printers.JSONResult{
+				Issues: issues,
+				Report: &report.Data{},
+			}, nil)
+
+			fake := newFakeGitHub(t)
+			fake.Diff = fixture.Diff
+			fake.Comments = tt.comments
+			fake.Files = make(map[string]string)
+			for path, content := range tt.baseFiles {
+				fake.Files[path+"@"+fixture.Base.String()] = content
+			}
+
+			srv, err := NewServer(&ServerOptions{
+				AppID:         1,
+				PrivateKey:    newPrivateKey(t),
+				WebhookSecret: "webhook",
+				GitHubURL:     fake.URL,
+				QueueSize:     1,
+				Options: &Options{
+					Logger:           logger{},
+					CacheDir:         t.TempDir(),
+					LinterConfig:     config.Config{Run: config.Run{Config: ".golangci.yml"}},
+					Approve:          true,
+					RequestChanges:   true,
+					OutdatedComments: OutdatedCommentsResolve,
+					Status:           true,
+					ConfigSource:     tt.configSource,
+				},
+			})
+			require.NoError(t, err)
+
+			rec := sendWebhook(t, srv, "webhook", "pull_request", &github.PullRequestEvent{
+				Action:       github.String("synchronize"),
+				PullRequest:  harnessPullRequest(gitServer.URL, fixture),
+				Installation: &github.Installation{ID: github.Int64(2)},
+			})
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			runner := nextRunner(t, srv)
+			runner.Options.Context = context.Background()
+			err = runner.Run()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			review := fake.review()
+			require.Equal(t, fixture.Head.String(), review.GetCommitID())
+			require.Equal(t, tt.event, review.GetEvent())
+			if len(tt.lines) > 0 {
+				require.NotEmpty(t, review.GetBody())
+			}
+			require.Contains(t, review.GetBody(), tt.body)
+			var lines []int
+			for _, comment := range review.Comments {
+				require.Equal(t, tt.path, comment.GetPath())
+				require.Contains(t, comment.GetBody(), tt.text)
+				lines = append(lines, comment.GetLine())
+			}
+			require.Equal(t, tt.lines, lines)
+			require.Equal(t, tt.statuses, fake.Statuses)
+
+			buf, err := ioutil.ReadFile(calls)
+			if !tt.linted {
+				require.True(t, err != nil || !containsLine(string(buf), "run"), string(buf))
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(string(buf), "run --config="), string(buf))
+		})
+	}
+}
+
+func TestEndToEndInvalidSignature(t *testing.T) {
+	fake := newFakeGitHub(t)
+	srv, err := NewServer(&ServerOptions{
+		AppID:         1,
+		PrivateKey:    newPrivateKey(t),
+		WebhookSecret: "webhook",
+		GitHubURL:     fake.URL,
+		QueueSize:     1,
+		Options:       &Options{Logger: logger{}},
+	})
+	require.NoError(t, err)
+
+	rec := sendWebhook(t, srv, "other", "pull_request", &github.PullRequestEvent{Action: github.String("opened")})
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Len(t, srv.queue, 0)
+}
+
+const (
+	autofixBase = "package main\n\n// recieve is not changed\nfunc main() {}\n"
+	autofixHead = "package main\n\n// recieve is not changed\nfunc main() {}\n\n// recieve is added\n// teh is added\nfunc other() {}\n"
+	// autofixFixed is what golangci-lint --fix writes, it fixes a line outside of the patch and leaves teh
+	autofixFixed = "package main\n\n// receive is not changed\nfunc main() {}\n\n// receive is added\n// teh is added\nfunc other() {}\n"
+	// autofixCommitted only contains the fix of the added line
+	autofixCommitted = "package main\n\n// recieve is not changed\nfunc main() {}\n\n// receive is added\n// teh is added\nfunc other() {}\n"
+)
+
+// TestEndToEndAutofix runs a job in autofix mode and checks which fixes are pushed.
+func TestEndToEndAutofix(t *testing.T) {
+	misspell := func(line int, word, fix string) result.Issue {
+		return result.Issue{
+			FromLinter: "misspell",
+			Text:       fmt.Sprintf("`%s` is a misspelling of `%s`", word, fix),
+			Pos:        token.Position{Filename: "main.go", Line: line},
+			Replacement: &result.Replacement{
+				Inline: &result.InlineFix{StartCol: 3, Length: len(word), NewString: fix},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		// protected refuses pushes to the head branch
+		protected bool
+		// fork is the full name of the head repository if the pull request is from a fork
+		fork string
+		// ref the fixes are pushed to, empty if nothing is pushed
+		ref          string
+		body         string
+		pullRequests int
+		// lines of the issues that are still commented
+		lines []int
+	}{
+		{
+			name:  "push",
+			ref:   "refs/heads/feature",
+			body:  "golangci-lint-runner fixed 1 issues in ",
+			lines: []int{7},
+		},
+		{
+			name:         "pull request",
+			protected:    true,
+			ref:          "refs/heads/golangci-lint-runner/fix-1",
+			body:         "golangci-lint-runner opened #2 to fix 1 issues",
+			pullRequests: 1,
+			lines:        []int{7},
+		},
+		{
+			name:  "fork",
+			fork:  "fork/repo",
+			body:  "golangci-lint-runner did not fix 2 issues because the pull request is from the fork fork/repo",
+			lines: []int{6, 7},
+		},
+	}
+	issues := []result.Issue{misspell(6, "recieve", "receive"), misspell(7, "teh", "the")}
+	// the comments refer to the head commit, not to the fixed files
+	headDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(headDir, "main.go"), []byte(autofixHead), 0600))
+	fingerprints := map[int]string{}
+	for i, fingerprint := range issueFingerprints(headDir, issues) {
+		fingerprints[issues[i].Line()] = fingerprint
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			fixture := newFixtureRepo(t, root, "repo", map[string]string{"main.go": autofixBase}, map[string]string{"main.go": autofixHead})
+			repo, err := git.PlainOpen(filepath.Join(root, fixture.Name))
+			require.NoError(t, err)
+			if tt.protected {
+				hook := "#!/bin/sh\nwhile read old new ref; do\n  if [ \"$ref\" = refs/heads/feature ]; then echo protected; exit 1; fi\ndone\n"
+				require.NoError(t, os.MkdirAll(filepath.Join(root, fixture.Name, ".git", "hooks"), 0700))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(root, fixture.Name, ".git", "hooks", "pre-receive"), []byte(hook), 0700))
+			}
+
+			gitServer := newGitServer(t, root, harnessInstallationToken)
+			installStubLinter(t, &printers.JSONResult{
+				Issues: issues,
+				Report: &report.Data{},
+			}, map[string]string{"main.go": autofixFixed})
+
+			fake := newFakeGitHub(t)
+			fake.Diff = fixture.Diff
+
+			srv, err := NewServer(&ServerOptions{
+				AppID:         1,
+				PrivateKey:    newPrivateKey(t),
+				WebhookSecret: "webhook",
+				GitHubURL:     fake.URL,
+				QueueSize:     1,
+				Options: &Options{
+					Logger:         logger{},
+					CacheDir:       t.TempDir(),
+					LinterConfig:   config.Config{Run: config.Run{Config: ".golangci.yml"}},
+					RequestChanges: true,
+					Autofix:        true,
+				},
+			})
+			require.NoError(t, err)
+
+			pr := harnessPullRequest(gitServer.URL, fixture)
+			if tt.fork != "" {
+				head := *pr.Head.Repo
+				head.FullName = github.String(tt.fork)
+				pr.Head.Repo = &head
+			}
+			rec := sendWebhook(t, srv, "webhook", "pull_request", &github.PullRequestEvent{
+				Action:       github.String("synchronize"),
+				PullRequest:  pr,
+				Installation: &github.Installation{ID: github.Int64(2)},
+			})
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			runner := nextRunner(t, srv)
+			runner.Options.Context = context.Background()
+			require.NoError(t, runner.Run())
+
+			if tt.ref == "" {
+				ref, err := repo.Reference(plumbing.ReferenceName("refs/heads/feature"), true)
+				require.NoError(t, err)
+				require.Equal(t, fixture.Head, ref.Hash())
+			} else {
+				ref, err := repo.Reference(plumbing.ReferenceName(tt.ref), true)
+				require.NoError(t, err)
+				commit, err := repo.CommitObject(ref.Hash())
+				require.NoError(t, err)
+				require.Equal(t, autofixAuthorName, commit.Author.Name)
+				require.Equal(t, []plumbing.Hash{fixture.Head}, commit.ParentHashes)
+				file, err := commit.File("main.go")
+				require.NoError(t, err)
+				content, err := file.Contents()
+				require.NoError(t, err)
+				require.Equal(t, autofixCommitted, content)
+			}
+
+			// the issues that were not fixed are still commented
+			review := fake.review()
+			require.Equal(t, ReviewEventRequestChanges, review.GetEvent())
+			require.Contains(t, review.GetBody(), tt.body)
+			var lines []int
+			for _, comment := range review.Comments {
+				lines = append(lines, comment.GetLine())
+				require.Contains(t, comment.GetBody(), fingerprintMarker(fingerprints[comment.GetLine()]))
+			}
+			require.Equal(t, tt.lines, lines)
+
+			require.Len(t, fake.PullRequests, tt.pullRequests)
+			for _, pr := range fake.PullRequests {
+				require.Equal(t, "golangci-lint-runner/fix-1", pr.GetHead())
+				require.Equal(t, "feature", pr.GetBase())
+				require.Equal(t, "golangci-lint fixes for #1", pr.GetTitle())
+				require.Equal(t, "Fixes the issues golangci-lint found in #1.", pr.GetBody())
+			}
+		})
+	}
+}
+
+// TestEndToEndOutdatedComments checks which threads of previous runs are resolved, minimized or replied to.
+func TestEndToEndOutdatedComments(t *testing.T) {
+	marker := func(fingerprint string) string {
+		return "errcheck\n\n" + fingerprintMarker(fingerprint)
+	}
+	threads := []fakeThread{
+		// still reported
+		{ID: "T1", CommentID: "C1", DatabaseID: 1, Path: "main.go", Body: marker(e2eFingerprint(t))},
+		{ID: "T2", CommentID: "C2", DatabaseID: 2, Path: "main.go", Body: marker("0000")},
+		{ID: "T3", CommentID: "C3", DatabaseID: 3, Path: "main.go", Body: marker("1111"), Resolved: true},
+		// not ours
+		{ID: "T4", CommentID: "C4", DatabaseID: 4, Path: "main.go", Body: "please check the error"},
+		{ID: "T5", CommentID: "C5", DatabaseID: 5, Path: "main.go", Body: marker("2222"), Minimized: true},
+		{ID: "T6", CommentID: "C6", DatabaseID: 6, Path: "main.go", Body: marker("3333"), LastBody: "fixed\n\n" + outdatedReplyMarker},
+	}
+
+	tests := []struct {
+		action    string
+		mutations []string
+		// replies are the ids of the comments that were replied to
+		replies []int64
+	}{
+		{
+			action: OutdatedCommentsKeep,
+		},
+		{
+			action:    OutdatedCommentsResolve,
+			mutations: []string{"resolveReviewThread T2", "resolveReviewThread T5", "resolveReviewThread T6"},
+		},
+		{
+			action:    OutdatedCommentsMinimize,
+			mutations: []string{"minimizeComment C2", "minimizeComment C6"},
+		},
+		{
+			action:  OutdatedCommentsReply,
+			replies: []int64{2, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			root := t.TempDir()
+			fixture := newFixtureRepo(t, root, "repo", map[string]string{"main.go": e2eBase}, map[string]string{"main.go": e2eHead})
+			gitServer := newGitServer(t, root, harnessInstallationToken)
+			installStubLinter(t, &printers.JSONResult{
+				Issues: []result.Issue{e2eIssue},
+				Report: &report.Data{},
+			}, nil)
+
+			fake := newFakeGitHub(t)
+			fake.Diff = fixture.Diff
+			fake.Threads = threads
+
+			srv, err := NewServer(&ServerOptions{
+				AppID:         1,
+				PrivateKey:    newPrivateKey(t),
+				WebhookSecret: "webhook",
+				GitHubURL:     fake.URL,
+				QueueSize:     1,
+				Options: &Options{
+					Logger:           logger{},
+					CacheDir:         t.TempDir(),
+					LinterConfig:     config.Config{Run: config.Run{Config: ".golangci.yml"}},
+					OutdatedComments: tt.action,
+				},
+			})
+			require.NoError(t, err)
+
+			rec := sendWebhook(t, srv, "webhook", "pull_request", &github.PullRequestEvent{
+				Action:       github.String("synchronize"),
+				PullRequest:  harnessPullRequest(gitServer.URL, fixture),
+				Installation: &github.Installation{ID: github.Int64(2)},
+			})
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			runner := nextRunner(t, srv)
+			runner.Options.Context = context.Background()
+			require.NoError(t, runner.Run())
+
+			require.Equal(t, tt.mutations, fake.Mutations)
+			var replies []int64
+			for _, reply := range fake.Replies {
+				require.Contains(t, reply.GetBody(), outdatedReplyMarker)
+				replies = append(replies, reply.GetInReplyTo())
+			}
+			require.Equal(t, tt.replies, replies)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package golangci_lint_runner
+
+import (
+	"fmt"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/talon-one/golangci-lint-runner/internal/export"
+)
+
+// ReportFormats returns the formats that can be used in Options.Reports.
+func ReportFormats() []string {
+	return export.Formats()
+}
+
+// writeReports writes the issues of the repository in repoDir to the files in Options.Reports.
+func (runner *Runner) writeReports(repoDir string, issues []result.Issue, version string) error {
+	if len(runner.Options.Reports) == 0 {
+		return nil
+	}
+
+	fingerprints := issueFingerprints(repoDir, issues)
+	r := export.Report{
+		Version: version,
+		Issues:  make([]export.Issue, len(issues)),
+	}
+	for i := range issues {
+		r.Issues[i] = export.Issue{Issue: issues[i], Fingerprint: fingerprints[i]}
+		r.Issues[i].Severity = issueSeverity(&issues[i])
+	}
+
+	for format, path := range runner.Options.Reports {
+		runner.Options.Logger.Debug("writing %s report to %s", format, path)
+		if err := export.WriteFile(path, format, &r); err != nil {
+			return fmt.Errorf("unable to write %s report to %s: %w", format, path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package golangci_lint_runner
+
+import (
+	"crypto/sha1" // nolint:gosec // not used for security
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+)
+
+var (
+	// matches our marker with an optional fingerprint, e.g. <!-- golangci-lint-runner fingerprint:0123abcd -->
+	markerRegexp = regexp.MustCompile(`<!-- golangci-lint-runner(?: fingerprint:([0-9a-f]+))? -->`)
+	numberRegexp = regexp.MustCompile(`[0-9]+`)
+)
+
+// fingerprintMarker returns the hidden marker for a comment with the fingerprint.
+func fingerprintMarker(fingerprint string) string {
+	return fmt.Sprintf("<!-- golangci-lint-runner fingerprint:%s -->", fingerprint)
+}
+
+// parseMarker reports whether body contains our marker and returns the fingerprint (if present).
+func parseMarker(body string) (fingerprint string, ok bool) {
+	m := markerRegexp.FindStringSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// stripMarker removes our marker from the body.
+func stripMarker(body string) string {
+	return strings.TrimSpace(markerRegexp.ReplaceAllString(body, ""))
+}
+
+// normalizeMessage removes everything from the message that changes when unrelated code changes
+// (e.g. line numbers, counts or whitespace).
+func normalizeMessage(s string) string {
+	s = numberRegexp.ReplaceAllString(s, "#")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// fingerprintContext is the number of lines before and after an issue that are part of its fingerprint.
+const fingerprintContext = 2
+
+// issueFingerprints returns a fingerprint for each issue that stays the same if the issue moves
+// (e.g. because lines were added above) but the code around it and the message does not change.
+// The fingerprint consists of the linter, the file, the normalized message and the source lines of the issue
+// with fingerprintContext lines around it, read from the files in repoDir. If a file can not be read (or repoDir
+// is empty) only the source lines golangci-lint reported are used.
+// Identical issues in the same file are counted to keep the fingerprints unique.
+func issueFingerprints(repoDir string, issues []result.Issue) []string {
+	fingerprints := make([]string, len(issues))
+	seen := make(map[string]int)
+	files := make(map[string][]string)
+	for i := range issues {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s\x00%s\x00%s\x00", issues[i].FromLinter, issues[i].FilePath(), normalizeMessage(issues[i].Text))
+		for _, line := range sourceWindow(repoDir, files, &issues[i]) {
+			fmt.Fprintf(&sb, "%s\x00", strings.TrimSpace(line))
+		}
+		key := sb.String()
+		n := seen[key]
+		seen[key]++
+
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s%d", key, n))) // nolint:gosec // not used for security
+		fingerprints[i] = hex.EncodeToString(sum[:8])
+	}
+	return fingerprints
+}
+
+// sourceWindow returns the lines of the issue with fingerprintContext lines around it,
+// files caches the lines of the files that were read.
+func sourceWindow(repoDir string, files map[string][]string, issue *result.Issue) []string {
+	if repoDir == "" || issue.Line() <= 0 {
+		return issue.SourceLines
+	}
+	lines, ok := files[issue.FilePath()]
+	if !ok {
+		buf, err := ioutil.ReadFile(filepath.Join(repoDir, issue.FilePath()))
+		if err == nil {
+			lines = strings.Split(string(buf), "\n")
+		}
+		files[issue.FilePath()] = lines
+	}
+	r := issue.GetLineRange()
+	if r.To > len(lines) {
+		return issue.SourceLines
+	}
+	from := r.From - 1 - fingerprintContext
+	if from < 0 {
+		from = 0
+	}
+	to := r.To + fingerprintContext
+	if to > len(lines) {
+		to = len(lines)
+	}
+	return lines[from:to]
+}
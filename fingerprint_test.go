@@ -0,0 +1,174 @@
+package golangci_lint_runner
+
+import (
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueFingerprints(t *testing.T) {
+	issue := result.Issue{
+		FromLinter:  "errcheck",
+		Text:        "Error return value of `f.Close` is not checked",
+		SourceLines: []string{"\tf.Close()"},
+		Pos:         token.Position{Filename: "main.go", Line: 10},
+	}
+
+	tests := []struct {
+		name  string
+		other func(result.Issue) result.Issue
+		equal bool
+	}{
+		{
+			name: "moved",
+			other: func(i result.Issue) result.Issue {
+				i.Pos.Line = 20
+				return i
+			},
+			equal: true,
+		},
+		{
+			name: "indentation changed",
+			other: func(i result.Issue) result.Issue {
+				i.SourceLines = []string{"\t\tf.Close()"}
+				return i
+			},
+			equal: true,
+		},
+		{
+			name: "different message",
+			other: func(i result.Issue) result.Issue {
+				i.Text = "Error return value of `f.Sync` is not checked"
+				return i
+			},
+			equal: false,
+		},
+		{
+			name: "different linter",
+			other: func(i result.Issue) result.Issue {
+				i.FromLinter = "gosec"
+				return i
+			},
+			equal: false,
+		},
+		{
+			name: "different file",
+			other: func(i result.Issue) result.Issue {
+				i.Pos.Filename = "other.go"
+				return i
+			},
+			equal: false,
+		},
+		{
+			name: "different code",
+			other: func(i result.Issue) result.Issue {
+				i.SourceLines = []string{"\tg.Close()"}
+				return i
+			},
+			equal: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := issueFingerprints("", []result.Issue{issue})
+			b := issueFingerprints("", []result.Issue{tt.other(issue)})
+			if tt.equal {
+				require.Equal(t, a, b)
+			} else {
+				require.NotEqual(t, a, b)
+			}
+		})
+	}
+
+	t.Run("identical issues get unique fingerprints", func(t *testing.T) {
+		fingerprints := issueFingerprints("", []result.Issue{issue, issue})
+		require.NotEqual(t, fingerprints[0], fingerprints[1])
+		require.Equal(t, issueFingerprints("", []result.Issue{issue})[0], fingerprints[0])
+	})
+
+	t.Run("message numbers are ignored", func(t *testing.T) {
+		a := issue
+		a.Text = "line is 130 characters"
+		b := issue
+		b.Text = "line is 131 characters"
+		require.Equal(t, issueFingerprints("", []result.Issue{a}), issueFingerprints("", []result.Issue{b}))
+	})
+}
+
+func TestIssueFingerprintsContext(t *testing.T) {
+	issue := result.Issue{
+		FromLinter:  "errcheck",
+		Text:        "Error return value of `f.Close` is not checked",
+		SourceLines: []string{"\tf.Close()"},
+		Pos:         token.Position{Filename: "main.go", Line: 5},
+	}
+	const content = "package main\n\nfunc main() {\n\tf := open()\n\tf.Close()\n}\n"
+
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		equal   bool
+	}{
+		{
+			name:    "moved",
+			content: "package main\n\nimport \"os\"\n\nfunc main() {\n\tf := open()\n\tf.Close()\n}\n",
+			line:    7,
+			equal:   true,
+		},
+		{
+			name:    "surrounding code changed",
+			content: "package main\n\nfunc main() {\n\tf := create()\n\tf.Close()\n}\n",
+			line:    5,
+		},
+		{
+			name:    "code outside of the context changed",
+			content: "package other\n\nfunc main() {\n\tf := open()\n\tf.Close()\n}\n",
+			line:    5,
+			equal:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := t.TempDir(), t.TempDir()
+			require.NoError(t, ioutil.WriteFile(filepath.Join(a, "main.go"), []byte(content), 0600))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(b, "main.go"), []byte(tt.content), 0600))
+			other := issue
+			other.Pos.Line = tt.line
+			if tt.equal {
+				require.Equal(t, issueFingerprints(a, []result.Issue{issue}), issueFingerprints(b, []result.Issue{other}))
+			} else {
+				require.NotEqual(t, issueFingerprints(a, []result.Issue{issue}), issueFingerprints(b, []result.Issue{other}))
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		require.Equal(t, issueFingerprints("", []result.Issue{issue}), issueFingerprints(t.TempDir(), []result.Issue{issue}))
+	})
+}
+
+func TestParseMarker(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		fingerprint string
+		ok          bool
+	}{
+		{name: "no marker", body: "unused variable", ok: false},
+		{name: "marker without fingerprint", body: "unused variable\n\n<!-- golangci-lint-runner -->", ok: true},
+		{name: "marker with fingerprint", body: "unused variable\n\n" + fingerprintMarker("0123456789abcdef"), fingerprint: "0123456789abcdef", ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fingerprint, ok := parseMarker(tt.body)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.fingerprint, fingerprint)
+			require.Equal(t, "unused variable", stripMarker(tt.body))
+		})
+	}
+}
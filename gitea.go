@@ -0,0 +1,373 @@
+package golangci_lint_runner
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/talon-one/golangci-lint-runner/internal"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+// giteaPageSize is the number of items requested per page, Gitea caps it at its MAX_RESPONSE_ITEMS (default 50).
+const giteaPageSize = 50
+
+// GiteaOptions configure the Gitea (and Forgejo) support of the server.
+type GiteaOptions struct {
+	// URL of the Gitea instance, e.g. https://gitea.example.com
+	URL string
+	// Token is an access token with write access to repositories and issues
+	Token string
+	// WebhookSecret is the secret of the pull request webhook
+	WebhookSecret string
+}
+
+type giteaReview struct {
+	ID int64 `json:"id"`
+}
+
+type giteaReviewComment struct {
+	ID       int64  `json:"id"`
+	Body     string `json:"body"`
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+}
+
+type giteaCreateReviewComment struct {
+	Path        string `json:"path"`
+	Body        string `json:"body"`
+	NewPosition int    `json:"new_position"`
+}
+
+type giteaCreateReview struct {
+	CommitID string                     `json:"commit_id,omitempty"`
+	Body     string                     `json:"body"`
+	Event    string                     `json:"event"`
+	Comments []giteaCreateReviewComment `json:"comments,omitempty"`
+}
+
+// GiteaProvider is the Provider for Gitea and Forgejo pull requests.
+// Gitea has no api to resolve or reply to review comments, so outdated comments are kept.
+// Multi line comments are attached to their last line.
+type GiteaProvider struct {
+	client *apiClient
+	token  string
+	owner  string
+	name   string
+	number int
+	meta   MetaData
+}
+
+// NewGiteaProvider creates a provider for the pull request number in owner/name on the Gitea instance baseURL.
+func NewGiteaProvider(baseURL, token, owner, name string, number int) *GiteaProvider {
+	return &GiteaProvider{
+		client: &apiClient{
+			name:       "gitea",
+			baseURL:    strings.TrimSuffix(baseURL, "/") + "/api/v1/",
+			token:      token,
+			header:     "Authorization",
+			prefix:     "token ",
+			httpClient: http.DefaultClient,
+		},
+		token:  token,
+		owner:  owner,
+		name:   name,
+		number: number,
+	}
+}
+
+func (p *GiteaProvider) repoPath(format string, a ...interface{}) string {
+	return fmt.Sprintf("repos/%s/%s", url.PathEscape(p.owner), url.PathEscape(p.name)) + fmt.Sprintf(format, a...)
+}
+
+func (p *GiteaProvider) Meta(ctx context.Context) (MetaData, error) {
+	// the pull request objects of gitea match the ones of github
+	var pr github.PullRequest
+	if _, err := p.client.do(ctx, http.MethodGet, p.repoPath("/pulls/%d", p.number), nil, nil, &pr); err != nil {
+		return MetaData{}, fmt.Errorf("unable to get pull request: %w", err)
+	}
+
+	var meta MetaData
+	meta.PullRequestNumber = pr.GetNumber()
+	if meta.PullRequestNumber == 0 {
+		return MetaData{}, errors.New("unable to get number from pull request")
+	}
+
+	meta.PullRequestURL = pr.GetHTMLURL()
+	if meta.PullRequestURL == "" {
+		return MetaData{}, errors.New("unable to get url from pull request")
+	}
+	meta.Reference = fmt.Sprintf("#%d", meta.PullRequestNumber)
+
+	var err error
+	if pr.GetBase() == nil {
+		return MetaData{}, errors.New("unable to get base")
+	}
+	meta.Base, err = getBranchMeta(pr.GetBase())
+	if err != nil {
+		return MetaData{}, fmt.Errorf("unable to get branch meta for base: %w", err)
+	}
+
+	if pr.GetHead() == nil {
+		return MetaData{}, errors.New("unable to get head")
+	}
+	meta.Head, err = getBranchMeta(pr.GetHead())
+	if err != nil {
+		return MetaData{}, fmt.Errorf("unable to get branch meta for head: %w", err)
+	}
+
+	p.meta = meta
+	return meta, nil
+}
+
+func (p *GiteaProvider) Auth() transport.AuthMethod {
+	return &gitHttp.BasicAuth{
+		// gitea ignores the username if the password is a token
+		Username: "golangci-lint-runner",
+		Password: p.token,
+	}
+}
+
+func (p *GiteaProvider) Diff(ctx context.Context) ([]byte, error) {
+	return p.client.raw(ctx, p.repoPath("/pulls/%d.diff", p.number))
+}
+
+func (p *GiteaProvider) Comments(ctx context.Context) ([]*ReviewComment, error) {
+	var comments []*ReviewComment
+	for page := 1; ; page++ {
+		var reviews []giteaReview
+		_, err := p.client.do(ctx, http.MethodGet, p.repoPath("/pulls/%d/reviews", p.number), url.Values{
+			"page":  {strconv.Itoa(page)},
+			"limit": {strconv.Itoa(giteaPageSize)},
+		}, nil, &reviews)
+		if err != nil {
+			return nil, err
+		}
+		for _, review := range reviews {
+			var list []giteaReviewComment
+			if _, err := p.client.do(ctx, http.MethodGet, p.repoPath("/pulls/%d/reviews/%d/comments", p.number, review.ID), nil, nil, &list); err != nil {
+				return nil, err
+			}
+			for _, c := range list {
+				comments = append(comments, &ReviewComment{
+					ID:   github.Int64(c.ID),
+					Path: github.String(c.Path),
+					Body: github.String(c.Body),
+					Line: github.Int(c.Position),
+				})
+			}
+		}
+		if len(reviews) < giteaPageSize {
+			return comments, nil
+		}
+	}
+}
+
+func (p *GiteaProvider) CreateReview(ctx context.Context, review *Review) error {
+	request := giteaCreateReview{
+		CommitID: review.GetCommitID(),
+		Body:     review.GetBody(),
+	}
+	switch review.GetEvent() {
+	case ReviewEventApprove:
+		request.Event = "APPROVED"
+	case ReviewEventRequestChanges:
+		request.Event = "REQUEST_CHANGES"
+	default:
+		request.Event = "COMMENT"
+	}
+	for _, comment := range review.Comments {
+		request.Comments = append(request.Comments, giteaCreateReviewComment{
+			Path:        comment.GetPath(),
+			Body:        comment.GetBody(),
+			NewPosition: comment.GetLine(),
+		})
+	}
+	_, err := p.client.do(ctx, http.MethodPost, p.repoPath("/pulls/%d/reviews", p.number), nil, &request, nil)
+	return err
+}
+
+var errGiteaThreads = fmt.Errorf("gitea has no api for review threads: %w", ErrNotSupported)
+
+func (p *GiteaProvider) Threads(ctx context.Context) ([]*Thread, error) {
+	return nil, errGiteaThreads
+}
+
+func (p *GiteaProvider) ResolveThread(ctx context.Context, thread *Thread) error {
+	return errGiteaThreads
+}
+
+func (p *GiteaProvider) MinimizeThread(ctx context.Context, thread *Thread) error {
+	return errGiteaThreads
+}
+
+func (p *GiteaProvider) ReplyToThread(ctx context.Context, thread *Thread, body string) error {
+	return errGiteaThreads
+}
+
+func (p *GiteaProvider) SetStatus(ctx context.Context, state, description string) error {
+	_, err := p.client.do(ctx, http.MethodPost, p.repoPath("/statuses/%s", p.meta.Head.SHA), nil, map[string]string{
+		"state":       state,
+		"description": description,
+		"context":     statusContext,
+	}, nil)
+	return err
+}
+
+func (p *GiteaProvider) CreateFixPullRequest(ctx context.Context, branch, title, body string) (string, error) {
+	var pr github.PullRequest
+	_, err := p.client.do(ctx, http.MethodPost, p.repoPath("/pulls"), nil, map[string]string{
+		"head":  branch,
+		"base":  p.meta.Head.Ref,
+		"title": title,
+		"body":  body,
+	}, &pr)
+	if err == nil {
+		return fmt.Sprintf("#%d", pr.GetNumber()), nil
+	}
+
+	var prs []*github.PullRequest
+	_, listErr := p.client.do(ctx, http.MethodGet, p.repoPath("/pulls"), url.Values{
+		"state": {"open"},
+		"limit": {strconv.Itoa(giteaPageSize)},
+	}, nil, &prs)
+	if listErr == nil {
+		for _, pr := range prs {
+			if pr.GetHead().GetRef() == branch && pr.GetBase().GetRef() == p.meta.Head.Ref {
+				return fmt.Sprintf("#%d", pr.GetNumber()), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unable to create pull request: %w", err)
+}
+
+func (p *GiteaProvider) DefaultBranchCommit(ctx context.Context, owner, name string) (string, error) {
+	repo := fmt.Sprintf("repos/%s/%s", url.PathEscape(owner), url.PathEscape(name))
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if _, err := p.client.do(ctx, http.MethodGet, repo, nil, nil, &info); err != nil {
+		return "", err
+	}
+	var branch struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	if _, err := p.client.do(ctx, http.MethodGet, repo+"/branches/"+url.PathEscape(info.DefaultBranch), nil, nil, &branch); err != nil {
+		return "", err
+	}
+	return branch.Commit.ID, nil
+}
+
+func (p *GiteaProvider) File(ctx context.Context, owner, name, path, ref string) ([]byte, error) {
+	return p.client.raw(ctx, fmt.Sprintf("repos/%s/%s/raw/%s?ref=%s", url.PathEscape(owner), url.PathEscape(name), (&url.URL{Path: path}).EscapedPath(), url.QueryEscape(ref)))
+}
+
+type giteaPullRequestEvent struct {
+	Action     string `json:"action"`
+	Number     int    `json:"number"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+// validateGiteaSignature checks the hex encoded HMAC-SHA256 signature of the payload.
+func validateGiteaSignature(signature string, payload, secret []byte) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+func (srv *Server) handleGiteaEvent(writer http.ResponseWriter, request *http.Request) error {
+	srv.Options.Logger.Debug("got gitea event from %s", request.RemoteAddr)
+	gitea := srv.Options.Gitea
+	if gitea == nil {
+		return internal.WireError{
+			StatusCode:   http.StatusNotFound,
+			PublicError:  errors.New("gitea is not configured"),
+			PrivateError: errors.New("got gitea event, but gitea is not configured"),
+		}
+	}
+
+	payload, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return internal.WireError{
+			StatusCode:   http.StatusBadRequest,
+			PublicError:  errors.New("unable to read payload"),
+			PrivateError: fmt.Errorf("unable to read payload: %w", err),
+		}
+	}
+	// forgejo sends both headers
+	signature := request.Header.Get("X-Gitea-Signature")
+	if signature == "" {
+		signature = request.Header.Get("X-Forgejo-Signature")
+	}
+	if !validateGiteaSignature(signature, payload, []byte(gitea.WebhookSecret)) {
+		return internal.WireError{
+			StatusCode:   http.StatusBadRequest,
+			PublicError:  errors.New("unable to validate payload"),
+			PrivateError: errors.New("invalid gitea signature"),
+		}
+	}
+
+	eventType := request.Header.Get("X-Gitea-Event")
+	if eventType == "" {
+		eventType = request.Header.Get("X-Forgejo-Event")
+	}
+	if eventType != "pull_request" {
+		srv.Options.Logger.Warn("unhandled gitea event %s", eventType)
+		return nil
+	}
+
+	var event giteaPullRequestEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return internal.WireError{
+			StatusCode:   http.StatusBadRequest,
+			PublicError:  errors.New("unable to parse payload"),
+			PrivateError: fmt.Errorf("unable to parse payload: %w", err),
+		}
+	}
+
+	switch event.Action {
+	case "opened", "reopened", "synchronized":
+	default:
+		srv.Options.Logger.Warn("unhandled action %s", event.Action)
+		return nil
+	}
+
+	if event.Repository.Owner.Login == "" || event.Repository.Name == "" || event.Number == 0 {
+		return internal.WireError{
+			StatusCode:   http.StatusBadRequest,
+			PublicError:  errors.New("unable to get pull request from event"),
+			PrivateError: errors.New("unable to get pull request from event"),
+		}
+	}
+
+	delivery := request.Header.Get("X-Gitea-Delivery")
+	if delivery == "" {
+		delivery = request.Header.Get("X-Forgejo-Delivery")
+	}
+	opts := *srv.Options.Options
+	opts.Logger = withFields(opts.Logger, Fields{"delivery": delivery})
+	opts.Provider = NewGiteaProvider(gitea.URL, gitea.Token, event.Repository.Owner.Login, event.Repository.Name, event.Number)
+	return srv.enqueue(opts)
+}
@@ -0,0 +1,168 @@
+package golangci_lint_runner
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+const giteaPullRequest = `{
+  "number": 3,
+  "html_url": "https://gitea.example.com/org/repo/pulls/3",
+  "base": {"ref": "main", "sha": "base", "repo": {"name": "repo", "full_name": "org/repo", "clone_url": "https://gitea.example.com/org/repo.git", "owner": {"login": "org"}}},
+  "head": {"ref": "feature", "sha": "head", "repo": {"name": "repo", "full_name": "org/repo", "clone_url": "https://gitea.example.com/org/repo.git", "owner": {"login": "org"}}}
+}`
+
+type fakeGitea struct {
+	*httptest.Server
+	bodies map[string][]json.RawMessage
+}
+
+func newFakeGitea(t *testing.T) *fakeGitea {
+	f := &fakeGitea{bodies: make(map[string][]json.RawMessage)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token secret", r.Header.Get("Authorization"))
+		key := r.Method + " " + strings.TrimPrefix(r.URL.Path, "/api/v1")
+		if r.Method == http.MethodPost {
+			var body json.RawMessage
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			f.bodies[key] = append(f.bodies[key], body)
+		}
+		switch key {
+		case "GET /repos/org/repo/pulls/3":
+			fmt.Fprint(w, giteaPullRequest)
+		case "GET /repos/org/repo/pulls/3.diff":
+			fmt.Fprint(w, "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1,2 @@\n package main\n+var a = 1\n")
+		case "GET /repos/org/repo/pulls/3/reviews":
+			fmt.Fprint(w, `[{"id":5}]`)
+		case "GET /repos/org/repo/pulls/3/reviews/5/comments":
+			fmt.Fprint(w, `[{"id":8,"body":"issue","path":"main.go","position":2}]`)
+		case "GET /repos/org/.gitea":
+			fmt.Fprint(w, `{"default_branch":"main"}`)
+		case "GET /repos/org/.gitea/branches/main":
+			fmt.Fprint(w, `{"commit":{"id":"abc"}}`)
+		case "GET /repos/org/.gitea/raw/configs/golangci.yml":
+			require.Equal(t, "abc", r.URL.Query().Get("ref"))
+			fmt.Fprint(w, "linters: {}\n")
+		case "GET /repos/org/.gitea/raw/missing.yml":
+			http.NotFound(w, r)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestGiteaProvider(t *testing.T) {
+	f := newFakeGitea(t)
+	ctx := context.Background()
+	p := NewGiteaProvider(f.URL, "secret", "org", "repo", 3)
+
+	meta, err := p.Meta(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, meta.PullRequestNumber)
+	require.Equal(t, "https://gitea.example.com/org/repo/pulls/3", meta.PullRequestURL)
+	require.Equal(t, BranchMeta{OwnerName: "org", RepoName: "repo", FullName: "org/repo", CloneURL: "https://gitea.example.com/org/repo.git", SHA: "head", Ref: "feature"}, meta.Head)
+	require.Equal(t, "main", meta.Base.Ref)
+
+	buf, err := p.Diff(ctx)
+	require.NoError(t, err)
+	require.Contains(t, string(buf), "+var a = 1")
+
+	comments, err := p.Comments(ctx)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	require.Equal(t, "main.go", comments[0].GetPath())
+	require.Equal(t, 2, comments[0].GetLine())
+
+	err = p.CreateReview(ctx, &Review{
+		CommitID: github.String("head"),
+		Body:     github.String("summary"),
+		Event:    github.String(ReviewEventRequestChanges),
+		Comments: []*ReviewComment{{Path: github.String("main.go"), Body: github.String("issue"), Line: github.Int(2), StartLine: github.Int(1)}},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"commit_id": "head",
+		"body": "summary",
+		"event": "REQUEST_CHANGES",
+		"comments": [{"path": "main.go", "body": "issue", "new_position": 2}]
+	}`, string(f.bodies["POST /repos/org/repo/pulls/3/reviews"][0]))
+
+	require.NoError(t, p.SetStatus(ctx, StatusPending, "running"))
+	require.JSONEq(t, `{"state": "pending", "description": "running", "context": "golangci-lint"}`, string(f.bodies["POST /repos/org/repo/statuses/head"][0]))
+
+	_, err = p.Threads(ctx)
+	require.True(t, errors.Is(err, ErrNotSupported))
+
+	commit, err := p.DefaultBranchCommit(ctx, "org", ".gitea")
+	require.NoError(t, err)
+	require.Equal(t, "abc", commit)
+	content, err := p.File(ctx, "org", ".gitea", "configs/golangci.yml", commit)
+	require.NoError(t, err)
+	require.Equal(t, "linters: {}\n", string(content))
+	_, err = p.File(ctx, "org", ".gitea", "missing.yml", commit)
+	require.True(t, errors.Is(err, ErrNotFound), err)
+}
+
+func TestHandleGiteaEvent(t *testing.T) {
+	f := newFakeGitea(t)
+	srv, err := NewServer(&ServerOptions{
+		QueueSize: 1,
+		Gitea: &GiteaOptions{
+			URL:           f.URL,
+			Token:         "secret",
+			WebhookSecret: "webhook",
+		},
+		Options: &Options{
+			Logger:   logger{},
+			CacheDir: t.TempDir(),
+		},
+	})
+	require.NoError(t, err)
+
+	sign := func(payload string) string {
+		mac := hmac.New(sha256.New, []byte("webhook"))
+		mac.Write([]byte(payload))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	opened := `{"action":"opened","number":3,"repository":{"name":"repo","owner":{"login":"org"}}}`
+
+	tests := []struct {
+		name      string
+		header    string
+		event     string
+		payload   string
+		signature string
+		status    int
+		queued    int
+	}{
+		{name: "invalid signature", header: "Gitea", event: "pull_request", payload: opened, signature: sign("other"), status: http.StatusBadRequest},
+		{name: "other event", header: "Gitea", event: "push", payload: `{}`, signature: sign(`{}`), status: http.StatusOK},
+		{name: "closed", header: "Gitea", event: "pull_request", payload: `{"action":"closed"}`, signature: sign(`{"action":"closed"}`), status: http.StatusOK},
+		{name: "forgejo", header: "Forgejo", event: "pull_request", payload: opened, signature: sign(opened), status: http.StatusOK, queued: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/gitea", strings.NewReader(tt.payload))
+			req.Header.Set("X-"+tt.header+"-Event", tt.event)
+			req.Header.Set("X-"+tt.header+"-Signature", tt.signature)
+			rec := httptest.NewRecorder()
+			srv.handler(srv.handleGiteaEvent)(rec, req)
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			require.Len(t, srv.queue, tt.queued)
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package golangci_lint_runner
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/go-github/github"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+const reviewThreadsQuery = `query($owner: String!, $name: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          id
+          isResolved
+          comments(first: 1) {
+            nodes {
+              id
+              databaseId
+              path
+              body
+              isMinimized
+            }
+          }
+          lastComment: comments(last: 1) {
+            nodes {
+              body
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+const resolveReviewThreadMutation = `mutation($id: ID!) {
+  resolveReviewThread(input: {threadId: $id}) {
+    thread {
+      id
+    }
+  }
+}`
+
+const minimizeCommentMutation = `mutation($id: ID!) {
+  minimizeComment(input: {subjectId: $id, classifier: OUTDATED}) {
+    minimizedComment {
+      isMinimized
+    }
+  }
+}`
+
+type reviewThreadComment struct {
+	ID          string `json:"id"`
+	DatabaseID  int64  `json:"databaseId"`
+	Path        string `json:"path"`
+	Body        string `json:"body"`
+	IsMinimized bool   `json:"isMinimized"`
+}
+
+type reviewThread struct {
+	ID         string `json:"id"`
+	IsResolved bool   `json:"isResolved"`
+	Comments   struct {
+		Nodes []reviewThreadComment `json:"nodes"`
+	} `json:"comments"`
+	LastComment struct {
+		Nodes []reviewThreadComment `json:"nodes"`
+	} `json:"lastComment"`
+}
+
+// GitHubProvider is the Provider for GitHub pull requests.
+type GitHubProvider struct {
+	client      *github.Client
+	token       string
+	owner       string
+	name        string
+	number      int
+	pullRequest *github.PullRequest
+	meta        MetaData
+	// database ids of the first comments of the threads, to reply to them
+	commentIDs map[string]int64
+}
+
+// NewGitHubProvider creates a provider for the pull request number in owner/name,
+// pullRequest can be nil, the token is used to clone and push.
+func NewGitHubProvider(client *github.Client, token, owner, name string, number int, pullRequest *github.PullRequest) *GitHubProvider {
+	return &GitHubProvider{
+		client:      client,
+		token:       token,
+		owner:       owner,
+		name:        name,
+		number:      number,
+		pullRequest: pullRequest,
+		commentIDs:  make(map[string]int64),
+	}
+}
+
+func (p *GitHubProvider) Meta(ctx context.Context) (MetaData, error) {
+	if p.pullRequest == nil {
+		var err error
+		p.pullRequest, _, err = p.client.PullRequests.Get(ctx, p.owner, p.name, p.number)
+		if err != nil {
+			return MetaData{}, fmt.Errorf("unable to get pull request: %w", err)
+		}
+	}
+
+	var meta MetaData
+	meta.PullRequestNumber = p.pullRequest.GetNumber()
+	if meta.PullRequestNumber == 0 {
+		return MetaData{}, errors.New("unable to get number from pull request")
+	}
+
+	meta.PullRequestURL = p.pullRequest.GetHTMLURL()
+	if meta.PullRequestURL == "" {
+		return MetaData{}, errors.New("unable to get url from pull request")
+	}
+	meta.Reference = fmt.Sprintf("#%d", meta.PullRequestNumber)
+
+	var err error
+	base := p.pullRequest.GetBase()
+	if base == nil {
+		return MetaData{}, errors.New("unable to get base")
+	}
+	meta.Base, err = getBranchMeta(base)
+	if err != nil {
+		return MetaData{}, fmt.Errorf("unable to get branch meta for base: %w", err)
+	}
+
+	head := p.pullRequest.GetHead()
+	if head == nil {
+		return MetaData{}, errors.New("unable to get head")
+	}
+	meta.Head, err = getBranchMeta(head)
+	if err != nil {
+		return MetaData{}, fmt.Errorf("unable to get branch meta for head: %w", err)
+	}
+
+	p.meta = meta
+	return meta, nil
+}
+
+func getBranchMeta(branch *github.PullRequestBranch) (BranchMeta, error) {
+	sha := branch.GetSHA()
+	if sha == "" {
+		return BranchMeta{}, errors.New("unable to get sha")
+	}
+
+	ref := branch.GetRef()
+	if ref == "" {
+		return BranchMeta{}, errors.New("unable to get ref")
+	}
+
+	repo := branch.GetRepo()
+	if repo == nil {
+		return BranchMeta{}, errors.New("unable to get repo")
+	}
+
+	name := repo.GetName()
+	if name == "" {
+		return BranchMeta{}, errors.New("unable to get repo name")
+	}
+
+	fullName := repo.GetFullName()
+	if fullName == "" {
+		return BranchMeta{}, errors.New("unable to get repo fullname")
+	}
+
+	cloneURL := repo.GetCloneURL()
+	if cloneURL == "" {
+		return BranchMeta{}, errors.New("unable to get repo clone url")
+	}
+
+	owner := repo.GetOwner()
+	if owner == nil {
+		return BranchMeta{}, errors.New("unable to get repo owner")
+	}
+
+	login := owner.GetLogin()
+	if login == "" {
+		return BranchMeta{}, errors.New("unable to get owner login name")
+	}
+
+	return BranchMeta{
+		OwnerName: login,
+		RepoName:  name,
+		FullName:  fullName,
+		CloneURL:  cloneURL,
+		Ref:       ref,
+		SHA:       sha,
+	}, nil
+}
+
+func (p *GitHubProvider) Auth() transport.AuthMethod {
+	return &gitHttp.BasicAuth{
+		// can be anything expect empty
+		Username: "x-access-token",
+		Password: p.token,
+	}
+}
+
+func (p *GitHubProvider) Diff(ctx context.Context) ([]byte, error) {
+	s, _, err := p.client.PullRequests.GetRaw(ctx, p.meta.Base.OwnerName, p.meta.Base.RepoName, p.meta.PullRequestNumber, github.RawOptions{Type: github.Diff})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+func (p *GitHubProvider) Comments(ctx context.Context) ([]*ReviewComment, error) {
+	var comments []*ReviewComment
+	page := 1
+	for {
+		u := fmt.Sprintf("repos/%v/%v/pulls/%d/comments?page=%d&per_page=%d", p.meta.Base.OwnerName, p.meta.Base.RepoName, p.meta.PullRequestNumber, page, 30)
+		req, err := p.client.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		var list []*ReviewComment
+		res, err := p.client.Do(ctx, req, &list)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, list...)
+		if res.NextPage <= 0 {
+			return comments, nil
+		}
+		page = res.NextPage
+	}
+}
+
+func (p *GitHubProvider) CreateReview(ctx context.Context, review *Review) error {
+	u := fmt.Sprintf("repos/%v/%v/pulls/%d/reviews", p.meta.Base.OwnerName, p.meta.Base.RepoName, p.meta.PullRequestNumber)
+	req, err := p.client.NewRequest("POST", u, review)
+	if err != nil {
+		return err
+	}
+	_, err = p.client.Do(ctx, req, nil)
+	return err
+}
+
+func (p *GitHubProvider) Threads(ctx context.Context) ([]*Thread, error) {
+	var threads []*Thread
+	variables := map[string]interface{}{
+		"owner":  p.meta.Base.OwnerName,
+		"name":   p.meta.Base.RepoName,
+		"number": p.meta.PullRequestNumber,
+	}
+	for {
+		var data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+						Nodes []reviewThread `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		}
+		if err := p.graphQL(ctx, reviewThreadsQuery, variables, &data); err != nil {
+			return nil, err
+		}
+		page := data.Repository.PullRequest.ReviewThreads
+		for _, t := range page.Nodes {
+			if len(t.Comments.Nodes) == 0 {
+				continue
+			}
+			first := t.Comments.Nodes[0]
+			thread := Thread{
+				ID:        t.ID,
+				CommentID: first.ID,
+				Resolved:  t.IsResolved,
+				Path:      first.Path,
+				Body:      first.Body,
+				Minimized: first.IsMinimized,
+			}
+			if len(t.LastComment.Nodes) > 0 {
+				thread.LastBody = t.LastComment.Nodes[0].Body
+			}
+			p.commentIDs[t.ID] = first.DatabaseID
+			threads = append(threads, &thread)
+		}
+		if !page.PageInfo.HasNextPage {
+			return threads, nil
+		}
+		variables["cursor"] = page.PageInfo.EndCursor
+	}
+}
+
+func (p *GitHubProvider) ResolveThread(ctx context.Context, thread *Thread) error {
+	return p.graphQL(ctx, resolveReviewThreadMutation, map[string]interface{}{"id": thread.ID}, nil)
+}
+
+func (p *GitHubProvider) MinimizeThread(ctx context.Context, thread *Thread) error {
+	return p.graphQL(ctx, minimizeCommentMutation, map[string]interface{}{"id": thread.CommentID}, nil)
+}
+
+func (p *GitHubProvider) ReplyToThread(ctx context.Context, thread *Thread, body string) error {
+	id, ok := p.commentIDs[thread.ID]
+	if !ok {
+		return fmt.Errorf("unknown thread %s", thread.ID)
+	}
+	_, _, err := p.client.PullRequests.CreateComment(ctx, p.meta.Base.OwnerName, p.meta.Base.RepoName, p.meta.PullRequestNumber, &github.PullRequestComment{
+		Body:      github.String(body),
+		InReplyTo: github.Int64(id),
+	})
+	return err
+}
+
+func (p *GitHubProvider) SetStatus(ctx context.Context, state, description string) error {
+	_, _, err := p.client.Repositories.CreateStatus(ctx, p.meta.Base.OwnerName, p.meta.Base.RepoName, p.meta.Head.SHA, &github.RepoStatus{
+		State:       github.String(state),
+		Description: github.String(description),
+		Context:     github.String(statusContext),
+	})
+	return err
+}
+
+func (p *GitHubProvider) CreateFixPullRequest(ctx context.Context, branch, title, body string) (string, error) {
+	pr, _, err := p.client.PullRequests.Create(ctx, p.meta.Base.OwnerName, p.meta.Base.RepoName, &github.NewPullRequest{
+		Title:               github.String(title),
+		Head:                github.String(branch),
+		Base:                github.String(p.meta.Head.Ref),
+		Body:                github.String(body),
+		MaintainerCanModify: github.Bool(true),
+	})
+	if err == nil {
+		return fmt.Sprintf("#%d", pr.GetNumber()), nil
+	}
+
+	prs, _, listErr := p.client.PullRequests.List(ctx, p.meta.Base.OwnerName, p.meta.Base.RepoName, &github.PullRequestListOptions{
+		State: "open",
+		Head:  p.meta.Base.OwnerName + ":" + branch,
+		Base:  p.meta.Head.Ref,
+	})
+	if listErr != nil || len(prs) == 0 {
+		return "", fmt.Errorf("unable to create pull request: %w", err)
+	}
+	return fmt.Sprintf("#%d", prs[0].GetNumber()), nil
+}
+
+func (p *GitHubProvider) DefaultBranchCommit(ctx context.Context, owner, name string) (string, error) {
+	sha, res, err := p.client.Repositories.GetCommitSHA1(ctx, owner, name, "HEAD", "")
+	if err != nil {
+		return "", githubError(res, err)
+	}
+	return sha, nil
+}
+
+func (p *GitHubProvider) File(ctx context.Context, owner, name, path, ref string) ([]byte, error) {
+	file, _, res, err := p.client.Repositories.GetContents(ctx, owner, name, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		return nil, githubError(res, err)
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is a directory: %w", path, ErrNotFound)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", path, err)
+	}
+	return []byte(content), nil
+}
+
+// githubError wraps err of a 404 response with ErrNotFound.
+func githubError(res *github.Response, err error) error {
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	}
+	return err
+}
+
+type appTransport struct {
+	underlyingTransport http.RoundTripper
+	token               string
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Add("Accept", "application/vnd.github.machine-man-preview+json")
+	req.Header.Add("Authorization", "Bearer "+t.token)
+	return t.underlyingTransport.RoundTrip(req)
+}
+
+func makeAppClient(baseURL string, appID int64, privateKey *rsa.PrivateKey) (*github.Client, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.StandardClaims{
+		ExpiresAt: time.Now().Local().Add(time.Minute * 5).Unix(),
+		IssuedAt:  time.Now().Unix(),
+		Issuer:    strconv.FormatInt(appID, 10),
+	})
+
+	tokenString, err := token.SignedString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewGitHubClient(baseURL, &http.Client{Transport: &appTransport{underlyingTransport: http.DefaultTransport, token: tokenString}})
+}
+
+type installationTransport struct {
+	underlyingTransport http.RoundTripper
+	token               string
+}
+
+func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Add("Accept", "application/vnd.github.machine-man-preview+json")
+	req.Header.Add("Authorization", "token "+t.token)
+	return t.underlyingTransport.RoundTrip(req)
+}
+
+func makeInstallationClient(baseURL, token string) (*github.Client, error) {
+	return NewGitHubClient(baseURL, &http.Client{Transport: &installationTransport{underlyingTransport: http.DefaultTransport, token: token}})
+}
+
+// NewGitHubClient creates a client for the api at baseURL, an empty baseURL (or https://api.github.com) uses github.com.
+// For GitHub Enterprise Server the url of the instance (https://github.example.com) or of its api
+// (https://github.example.com/api/v3) can be used, uploads go to https://github.example.com/api/uploads.
+func NewGitHubClient(baseURL string, httpClient *http.Client) (*github.Client, error) {
+	if baseURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse github url %s: %w", baseURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("github url %s must be absolute", baseURL)
+	}
+	if u.Host == "api.github.com" || u.Host == "github.com" {
+		return github.NewClient(httpClient), nil
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	if path == "" {
+		path = "/api/v3"
+	}
+	u.Path = path + "/"
+	api := u.String()
+	u.Path = strings.TrimSuffix(path, "/v3") + "/uploads/"
+	return github.NewEnterpriseClient(api, u.String(), httpClient)
+}
@@ -0,0 +1,73 @@
+package golangci_lint_runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGitHubClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		api     string
+		upload  string
+		graphQL string
+		err     bool
+	}{
+		{name: "default", url: "", api: "https://api.github.com/", upload: "https://uploads.github.com/", graphQL: "https://api.github.com/graphql"},
+		{name: "github.com", url: "https://api.github.com", api: "https://api.github.com/", upload: "https://uploads.github.com/", graphQL: "https://api.github.com/graphql"},
+		{name: "enterprise host", url: "https://github.example.com", api: "https://github.example.com/api/v3/", upload: "https://github.example.com/api/uploads/", graphQL: "https://github.example.com/api/graphql"},
+		{name: "enterprise api", url: "https://github.example.com/api/v3/", api: "https://github.example.com/api/v3/", upload: "https://github.example.com/api/uploads/", graphQL: "https://github.example.com/api/graphql"},
+		{name: "relative", url: "github.example.com", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewGitHubClient(tt.url, nil)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.api, client.BaseURL.String())
+			require.Equal(t, tt.upload, client.UploadURL.String())
+			require.Equal(t, tt.graphQL, graphQLURL(client.BaseURL))
+		})
+	}
+}
+
+func TestGitHubProvider_File(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/org/.github/commits/HEAD":
+			fmt.Fprint(w, "abc")
+		case "/api/v3/repos/org/.github/contents/golangci.yml":
+			require.Equal(t, "abc", r.URL.Query().Get("ref"))
+			fmt.Fprint(w, `{"type":"file","encoding":"base64","content":"bGludGVyczoge30K"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	client, err := NewGitHubClient(srv.URL, nil)
+	require.NoError(t, err)
+	p := NewGitHubProvider(client, "token", "org", "repo", 1, nil)
+	ctx := context.Background()
+
+	commit, err := p.DefaultBranchCommit(ctx, "org", ".github")
+	require.NoError(t, err)
+	require.Equal(t, "abc", commit)
+	content, err := p.File(ctx, "org", ".github", "golangci.yml", commit)
+	require.NoError(t, err)
+	require.Equal(t, "linters: {}\n", string(content))
+
+	_, err = p.DefaultBranchCommit(ctx, "other", ".github")
+	require.True(t, errors.Is(err, ErrNotFound), err)
+	_, err = p.File(ctx, "org", ".github", "missing.yml", commit)
+	require.True(t, errors.Is(err, ErrNotFound), err)
+}
@@ -0,0 +1,529 @@
+package golangci_lint_runner
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/talon-one/golangci-lint-runner/internal"
+	"github.com/talon-one/golangci-lint-runner/internal/diff"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+// DefaultGitLabURL is the GitLab instance that is used if none is specified.
+const DefaultGitLabURL = "https://gitlab.com"
+
+// GitLabOptions configure the GitLab support of the server.
+type GitLabOptions struct {
+	// URL of the GitLab instance, defaults to DefaultGitLabURL
+	URL string
+	// Token is a personal, group or project access token with the api and write_repository scopes
+	Token string
+	// WebhookSecret is the secret token of the merge request webhook
+	WebhookSecret string
+}
+
+type gitLabDiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+type gitLabMergeRequest struct {
+	IID             int            `json:"iid"`
+	WebURL          string         `json:"web_url"`
+	SHA             string         `json:"sha"`
+	SourceBranch    string         `json:"source_branch"`
+	TargetBranch    string         `json:"target_branch"`
+	SourceProjectID int64          `json:"source_project_id"`
+	TargetProjectID int64          `json:"target_project_id"`
+	DiffRefs        gitLabDiffRefs `json:"diff_refs"`
+}
+
+type gitLabProject struct {
+	ID                int64  `json:"id"`
+	Path              string `json:"path"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	DefaultBranch     string `json:"default_branch"`
+	Namespace         struct {
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+}
+
+type gitLabDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	AMode       string `json:"a_mode"`
+	BMode       string `json:"b_mode"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+type gitLabLinePosition struct {
+	OldLine int `json:"old_line,omitempty"`
+	NewLine int `json:"new_line,omitempty"`
+}
+
+type gitLabPosition struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	OldPath      string `json:"old_path"`
+	NewPath      string `json:"new_path"`
+	OldLine      int    `json:"old_line,omitempty"`
+	NewLine      int    `json:"new_line,omitempty"`
+	LineRange    *struct {
+		Start gitLabLinePosition `json:"start"`
+		End   gitLabLinePosition `json:"end"`
+	} `json:"line_range,omitempty"`
+}
+
+type gitLabNote struct {
+	ID       int64           `json:"id"`
+	Type     string          `json:"type"`
+	Body     string          `json:"body"`
+	Resolved bool            `json:"resolved"`
+	Position *gitLabPosition `json:"position"`
+}
+
+type gitLabDiscussion struct {
+	ID    string       `json:"id"`
+	Notes []gitLabNote `json:"notes"`
+}
+
+// GitLabProvider is the Provider for GitLab merge requests.
+// GitLab has no reviews, so every comment is posted as a discussion and the summary as a note,
+// an approved review approves the merge request and changes can not be requested.
+type GitLabProvider struct {
+	client  *apiClient
+	project string
+	iid     int
+	mr      gitLabMergeRequest
+	patch   *diff.Diff
+}
+
+// NewGitLabProvider creates a provider for the merge request iid of the project (id or path) on the GitLab instance baseURL.
+func NewGitLabProvider(baseURL, token, project string, iid int) *GitLabProvider {
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+	return &GitLabProvider{
+		client: &apiClient{
+			name:       "gitlab",
+			baseURL:    strings.TrimSuffix(baseURL, "/") + "/api/v4/",
+			token:      token,
+			header:     "PRIVATE-TOKEN",
+			httpClient: http.DefaultClient,
+		},
+		project: project,
+		iid:     iid,
+	}
+}
+
+func (p *GitLabProvider) mergeRequestPath(format string, a ...interface{}) string {
+	return fmt.Sprintf("projects/%s/merge_requests/%d", url.PathEscape(p.project), p.iid) + fmt.Sprintf(format, a...)
+}
+
+func (p *GitLabProvider) Meta(ctx context.Context) (MetaData, error) {
+	if _, err := p.client.do(ctx, http.MethodGet, p.mergeRequestPath(""), nil, nil, &p.mr); err != nil {
+		return MetaData{}, fmt.Errorf("unable to get merge request: %w", err)
+	}
+	if p.mr.IID == 0 {
+		return MetaData{}, errors.New("unable to get iid from merge request")
+	}
+	if p.mr.WebURL == "" {
+		return MetaData{}, errors.New("unable to get url from merge request")
+	}
+
+	base, err := p.branchMeta(ctx, p.mr.TargetProjectID, p.mr.TargetBranch, p.mr.DiffRefs.StartSHA)
+	if err != nil {
+		return MetaData{}, fmt.Errorf("unable to get branch meta for target: %w", err)
+	}
+	head, err := p.branchMeta(ctx, p.mr.SourceProjectID, p.mr.SourceBranch, p.mr.SHA)
+	if err != nil {
+		return MetaData{}, fmt.Errorf("unable to get branch meta for source: %w", err)
+	}
+
+	return MetaData{
+		Base:              base,
+		Head:              head,
+		PullRequestNumber: p.mr.IID,
+		PullRequestURL:    p.mr.WebURL,
+		Reference:         fmt.Sprintf("!%d", p.mr.IID),
+	}, nil
+}
+
+func (p *GitLabProvider) branchMeta(ctx context.Context, projectID int64, ref, sha string) (BranchMeta, error) {
+	if sha == "" {
+		return BranchMeta{}, errors.New("unable to get sha")
+	}
+	if ref == "" {
+		return BranchMeta{}, errors.New("unable to get ref")
+	}
+	var project gitLabProject
+	if _, err := p.client.do(ctx, http.MethodGet, fmt.Sprintf("projects/%d", projectID), nil, nil, &project); err != nil {
+		return BranchMeta{}, fmt.Errorf("unable to get project %d: %w", projectID, err)
+	}
+	if project.HTTPURLToRepo == "" {
+		return BranchMeta{}, errors.New("unable to get project clone url")
+	}
+	return BranchMeta{
+		OwnerName: project.Namespace.FullPath,
+		RepoName:  project.Path,
+		FullName:  project.PathWithNamespace,
+		CloneURL:  project.HTTPURLToRepo,
+		Ref:       ref,
+		SHA:       sha,
+	}, nil
+}
+
+func (p *GitLabProvider) Auth() transport.AuthMethod {
+	return &gitHttp.BasicAuth{
+		Username: "oauth2",
+		Password: p.client.token,
+	}
+}
+
+// Diff builds a git diff from the diffs of the merge request.
+func (p *GitLabProvider) Diff(ctx context.Context) ([]byte, error) {
+	var buf bytes.Buffer
+	page := 1
+	for page > 0 {
+		var diffs []gitLabDiff
+		var err error
+		page, err = p.client.do(ctx, http.MethodGet, p.mergeRequestPath("/diffs"), url.Values{
+			"page":     {strconv.Itoa(page)},
+			"per_page": {"100"},
+		}, nil, &diffs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range diffs {
+			writeGitLabDiff(&buf, &diffs[i])
+		}
+	}
+
+	// the positions of the comments need the old line numbers
+	patch, err := diff.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse diff: %w", err)
+	}
+	p.patch = patch
+	return buf.Bytes(), nil
+}
+
+func writeGitLabDiff(w *bytes.Buffer, d *gitLabDiff) {
+	fmt.Fprintf(w, "diff --git a/%s b/%s\n", d.OldPath, d.NewPath)
+	oldName, newName := "a/"+d.OldPath, "b/"+d.NewPath
+	switch {
+	case d.NewFile:
+		fmt.Fprintf(w, "new file mode %s\n", d.BMode)
+		oldName = "/dev/null"
+	case d.DeletedFile:
+		fmt.Fprintf(w, "deleted file mode %s\n", d.AMode)
+		newName = "/dev/null"
+	case d.RenamedFile:
+		fmt.Fprintf(w, "rename from %s\nrename to %s\n", d.OldPath, d.NewPath)
+	}
+	if d.Diff == "" {
+		return
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+	w.WriteString(d.Diff)
+	if !strings.HasSuffix(d.Diff, "\n") {
+		w.WriteString("\n")
+	}
+}
+
+func (p *GitLabProvider) discussions(ctx context.Context) ([]gitLabDiscussion, error) {
+	var discussions []gitLabDiscussion
+	page := 1
+	for page > 0 {
+		var list []gitLabDiscussion
+		var err error
+		page, err = p.client.do(ctx, http.MethodGet, p.mergeRequestPath("/discussions"), url.Values{
+			"page":     {strconv.Itoa(page)},
+			"per_page": {"100"},
+		}, nil, &list)
+		if err != nil {
+			return nil, err
+		}
+		discussions = append(discussions, list...)
+	}
+	return discussions, nil
+}
+
+func (p *GitLabProvider) Comments(ctx context.Context) ([]*ReviewComment, error) {
+	discussions, err := p.discussions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var comments []*ReviewComment
+	for _, discussion := range discussions {
+		for _, note := range discussion.Notes {
+			if note.Type != "DiffNote" || note.Position == nil {
+				continue
+			}
+			comment := ReviewComment{
+				ID:   github.Int64(note.ID),
+				Path: github.String(note.Position.NewPath),
+				Body: github.String(note.Body),
+				Line: github.Int(note.Position.NewLine),
+			}
+			if r := note.Position.LineRange; r != nil && r.Start.NewLine != 0 && r.Start.NewLine != note.Position.NewLine {
+				comment.StartLine = github.Int(r.Start.NewLine)
+			}
+			comments = append(comments, &comment)
+		}
+	}
+	return comments, nil
+}
+
+// position returns the position of the comment, multi line comments are attached to their last line.
+func (p *GitLabProvider) position(comment *ReviewComment) (*gitLabPosition, error) {
+	position := gitLabPosition{
+		PositionType: "text",
+		BaseSHA:      p.mr.DiffRefs.BaseSHA,
+		StartSHA:     p.mr.DiffRefs.StartSHA,
+		HeadSHA:      p.mr.DiffRefs.HeadSHA,
+		OldPath:      comment.GetPath(),
+		NewPath:      comment.GetPath(),
+		NewLine:      comment.GetLine(),
+	}
+	if p.patch == nil {
+		return &position, nil
+	}
+	f := p.patch.File(comment.GetPath())
+	if f == nil {
+		return nil, fmt.Errorf("%s is not part of the diff", comment.GetPath())
+	}
+	if f.OldName != "" {
+		position.OldPath = f.OldName
+	}
+	line, ok := f.LineAt(comment.GetLine())
+	if !ok {
+		return nil, fmt.Errorf("line %d of %s is not part of the diff", comment.GetLine(), comment.GetPath())
+	}
+	// unchanged lines need both line numbers
+	if line.Kind != diff.Added {
+		position.OldLine = line.OldLine
+	}
+	return &position, nil
+}
+
+func (p *GitLabProvider) CreateReview(ctx context.Context, review *Review) error {
+	for _, comment := range review.Comments {
+		position, err := p.position(comment)
+		if err != nil {
+			return err
+		}
+		_, err = p.client.do(ctx, http.MethodPost, p.mergeRequestPath("/discussions"), nil, map[string]interface{}{
+			"body":     comment.GetBody(),
+			"position": position,
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("unable to create discussion on %s:%d: %w", comment.GetPath(), comment.GetLine(), err)
+		}
+	}
+
+	if body := review.GetBody(); body != "" {
+		if _, err := p.client.do(ctx, http.MethodPost, p.mergeRequestPath("/notes"), nil, map[string]string{"body": body}, nil); err != nil {
+			return fmt.Errorf("unable to create note: %w", err)
+		}
+	}
+
+	if review.GetEvent() == ReviewEventApprove {
+		if _, err := p.client.do(ctx, http.MethodPost, p.mergeRequestPath("/approve"), nil, map[string]string{"sha": p.mr.SHA}, nil); err != nil {
+			return fmt.Errorf("unable to approve: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *GitLabProvider) Threads(ctx context.Context) ([]*Thread, error) {
+	discussions, err := p.discussions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var threads []*Thread
+	for _, discussion := range discussions {
+		if len(discussion.Notes) == 0 {
+			continue
+		}
+		first := discussion.Notes[0]
+		if first.Type != "DiffNote" || first.Position == nil {
+			continue
+		}
+		threads = append(threads, &Thread{
+			ID:        discussion.ID,
+			CommentID: strconv.FormatInt(first.ID, 10),
+			Resolved:  first.Resolved,
+			Path:      first.Position.NewPath,
+			Body:      first.Body,
+			LastBody:  discussion.Notes[len(discussion.Notes)-1].Body,
+		})
+	}
+	return threads, nil
+}
+
+func (p *GitLabProvider) ResolveThread(ctx context.Context, thread *Thread) error {
+	_, err := p.client.do(ctx, http.MethodPut, p.mergeRequestPath("/discussions/%s", url.PathEscape(thread.ID)), url.Values{"resolved": {"true"}}, nil, nil)
+	return err
+}
+
+// MinimizeThread resolves the thread, GitLab can not hide comments.
+func (p *GitLabProvider) MinimizeThread(ctx context.Context, thread *Thread) error {
+	return p.ResolveThread(ctx, thread)
+}
+
+func (p *GitLabProvider) ReplyToThread(ctx context.Context, thread *Thread, body string) error {
+	_, err := p.client.do(ctx, http.MethodPost, p.mergeRequestPath("/discussions/%s/notes", url.PathEscape(thread.ID)), nil, map[string]string{"body": body}, nil)
+	return err
+}
+
+func (p *GitLabProvider) SetStatus(ctx context.Context, state, description string) error {
+	switch state {
+	case StatusFailure, StatusError:
+		state = "failed"
+	case StatusPending:
+		state = "running"
+	}
+	_, err := p.client.do(ctx, http.MethodPost, fmt.Sprintf("projects/%d/statuses/%s", p.mr.SourceProjectID, p.mr.SHA), nil, map[string]string{
+		"state":       state,
+		"ref":         p.mr.SourceBranch,
+		"name":        statusContext,
+		"description": description,
+	}, nil)
+	return err
+}
+
+func (p *GitLabProvider) CreateFixPullRequest(ctx context.Context, branch, title, body string) (string, error) {
+	path := fmt.Sprintf("projects/%d/merge_requests", p.mr.SourceProjectID)
+	var mr gitLabMergeRequest
+	_, err := p.client.do(ctx, http.MethodPost, path, nil, map[string]string{
+		"source_branch": branch,
+		"target_branch": p.mr.SourceBranch,
+		"title":         title,
+		"description":   body,
+	}, &mr)
+	if err == nil {
+		return fmt.Sprintf("!%d", mr.IID), nil
+	}
+
+	var mrs []gitLabMergeRequest
+	_, listErr := p.client.do(ctx, http.MethodGet, path, url.Values{
+		"state":         {"opened"},
+		"source_branch": {branch},
+		"target_branch": {p.mr.SourceBranch},
+	}, nil, &mrs)
+	if listErr != nil || len(mrs) == 0 {
+		return "", fmt.Errorf("unable to create merge request: %w", err)
+	}
+	return fmt.Sprintf("!%d", mrs[0].IID), nil
+}
+
+func (p *GitLabProvider) DefaultBranchCommit(ctx context.Context, owner, name string) (string, error) {
+	project := url.PathEscape(owner + "/" + name)
+	var info gitLabProject
+	if _, err := p.client.do(ctx, http.MethodGet, "projects/"+project, nil, nil, &info); err != nil {
+		return "", err
+	}
+	var commit struct {
+		ID string `json:"id"`
+	}
+	if _, err := p.client.do(ctx, http.MethodGet, "projects/"+project+"/repository/commits/"+url.PathEscape(info.DefaultBranch), nil, nil, &commit); err != nil {
+		return "", err
+	}
+	return commit.ID, nil
+}
+
+func (p *GitLabProvider) File(ctx context.Context, owner, name, path, ref string) ([]byte, error) {
+	return p.client.raw(ctx, fmt.Sprintf("projects/%s/repository/files/%s/raw?ref=%s", url.PathEscape(owner+"/"+name), url.PathEscape(path), url.QueryEscape(ref)))
+}
+
+type gitLabMergeRequestEvent struct {
+	ObjectKind string `json:"object_kind"`
+	Project    struct {
+		ID int64 `json:"id"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID             int    `json:"iid"`
+		Action          string `json:"action"`
+		OldRev          string `json:"oldrev"`
+		TargetProjectID int64  `json:"target_project_id"`
+	} `json:"object_attributes"`
+}
+
+func (srv *Server) handleGitLabEvent(writer http.ResponseWriter, request *http.Request) error {
+	srv.Options.Logger.Debug("got gitlab event from %s", request.RemoteAddr)
+	gitLab := srv.Options.GitLab
+	if gitLab == nil {
+		return internal.WireError{
+			StatusCode:   http.StatusNotFound,
+			PublicError:  errors.New("gitlab is not configured"),
+			PrivateError: errors.New("got gitlab event, but gitlab is not configured"),
+		}
+	}
+	if subtle.ConstantTimeCompare([]byte(request.Header.Get("X-Gitlab-Token")), []byte(gitLab.WebhookSecret)) != 1 {
+		return internal.WireError{
+			StatusCode:   http.StatusUnauthorized,
+			PublicError:  errors.New("unable to validate payload"),
+			PrivateError: errors.New("invalid gitlab token"),
+		}
+	}
+	if event := request.Header.Get("X-Gitlab-Event"); event != "Merge Request Hook" {
+		srv.Options.Logger.Warn("unhandled gitlab event %s", event)
+		return nil
+	}
+
+	var event gitLabMergeRequestEvent
+	if err := json.NewDecoder(request.Body).Decode(&event); err != nil {
+		return internal.WireError{
+			StatusCode:   http.StatusBadRequest,
+			PublicError:  errors.New("unable to parse payload"),
+			PrivateError: fmt.Errorf("unable to parse payload: %w", err),
+		}
+	}
+
+	switch event.ObjectAttributes.Action {
+	case "open", "reopen":
+	case "update":
+		// updates without oldrev change the title, labels etc.
+		if event.ObjectAttributes.OldRev == "" {
+			return nil
+		}
+	default:
+		srv.Options.Logger.Warn("unhandled action %s", event.ObjectAttributes.Action)
+		return nil
+	}
+
+	projectID := event.ObjectAttributes.TargetProjectID
+	if projectID == 0 {
+		projectID = event.Project.ID
+	}
+	if projectID == 0 || event.ObjectAttributes.IID == 0 {
+		return internal.WireError{
+			StatusCode:   http.StatusBadRequest,
+			PublicError:  errors.New("unable to get merge request from event"),
+			PrivateError: errors.New("unable to get merge request from event"),
+		}
+	}
+
+	opts := *srv.Options.Options
+	opts.Logger = withFields(opts.Logger, Fields{"delivery": request.Header.Get("X-Gitlab-Event-UUID")})
+	opts.Provider = NewGitLabProvider(gitLab.URL, gitLab.Token, strconv.FormatInt(projectID, 10), event.ObjectAttributes.IID)
+	return srv.enqueue(opts)
+}
@@ -0,0 +1,220 @@
+package golangci_lint_runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGitLab struct {
+	*httptest.Server
+	requests []string
+	bodies   map[string][]map[string]interface{}
+}
+
+func newFakeGitLab(t *testing.T) *fakeGitLab {
+	f := &fakeGitLab{bodies: make(map[string][]map[string]interface{})}
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, v string) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, v)
+	}
+	mux.HandleFunc("/api/v4/", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		key := r.Method + " " + strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4")
+		f.requests = append(f.requests, key)
+		if r.Method == http.MethodPost {
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			f.bodies[key] = append(f.bodies[key], body)
+		}
+
+		switch key {
+		case "GET /projects/1/merge_requests/7":
+			reply(w, `{"iid":7,"web_url":"https://gitlab.example.com/group/repo/-/merge_requests/7","sha":"head","source_branch":"feature","target_branch":"main","source_project_id":2,"target_project_id":1,"diff_refs":{"base_sha":"base","head_sha":"head","start_sha":"start"}}`)
+		case "GET /projects/1":
+			reply(w, `{"id":1,"path":"repo","path_with_namespace":"group/repo","http_url_to_repo":"https://gitlab.example.com/group/repo.git","namespace":{"full_path":"group"}}`)
+		case "GET /projects/2":
+			reply(w, `{"id":2,"path":"repo","path_with_namespace":"fork/repo","http_url_to_repo":"https://gitlab.example.com/fork/repo.git","namespace":{"full_path":"fork"}}`)
+		case "GET /projects/1/merge_requests/7/diffs":
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				reply(w, `[{"old_path":"main.go","new_path":"main.go","a_mode":"100644","b_mode":"100644","diff":"@@ -1,3 +1,4 @@\n package main\n \n+var a = 1\n func main() {}\n"}]`)
+				return
+			}
+			reply(w, `[{"old_path":"old.go","new_path":"new.go","renamed_file":true,"diff":""},{"old_path":"add.go","new_path":"add.go","new_file":true,"b_mode":"100644","diff":"@@ -0,0 +1 @@\n+package main\n"}]`)
+		case "GET /projects/group%2F.gitlab":
+			reply(w, `{"id":3,"default_branch":"main"}`)
+		case "GET /projects/group%2F.gitlab/repository/commits/main":
+			reply(w, `{"id":"abc"}`)
+		case "GET /projects/group%2F.gitlab/repository/files/golangci.yml/raw":
+			require.Equal(t, "abc", r.URL.Query().Get("ref"))
+			fmt.Fprint(w, "linters: {}\n")
+		case "GET /projects/group%2F.gitlab/repository/files/missing.yml/raw":
+			http.NotFound(w, r)
+			return
+		case "GET /projects/1/merge_requests/7/discussions":
+			reply(w, `[
+				{"id":"d1","notes":[{"id":11,"type":"DiffNote","body":"issue\n\n<!-- golangci-lint-runner:abc -->","resolved":false,"position":{"new_path":"main.go","new_line":3}},{"id":12,"body":"reply"}]},
+				{"id":"d2","notes":[{"id":21,"type":null,"body":"summary"}]}
+			]`)
+		default:
+			reply(w, `{}`)
+		}
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestGitLabProvider(t *testing.T) {
+	f := newFakeGitLab(t)
+	ctx := context.Background()
+	p := NewGitLabProvider(f.URL, "secret", "1", 7)
+
+	meta, err := p.Meta(ctx)
+	require.NoError(t, err)
+	require.Equal(t, MetaData{
+		Base:              BranchMeta{OwnerName: "group", RepoName: "repo", FullName: "group/repo", CloneURL: "https://gitlab.example.com/group/repo.git", SHA: "start", Ref: "main"},
+		Head:              BranchMeta{OwnerName: "fork", RepoName: "repo", FullName: "fork/repo", CloneURL: "https://gitlab.example.com/fork/repo.git", SHA: "head", Ref: "feature"},
+		PullRequestNumber: 7,
+		PullRequestURL:    "https://gitlab.example.com/group/repo/-/merge_requests/7",
+		Reference:         "!7",
+	}, meta)
+
+	buf, err := p.Diff(ctx)
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		"diff --git a/main.go b/main.go",
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -1,3 +1,4 @@",
+		" package main",
+		" ",
+		"+var a = 1",
+		" func main() {}",
+		"diff --git a/old.go b/new.go",
+		"rename from old.go",
+		"rename to new.go",
+		"diff --git a/add.go b/add.go",
+		"new file mode 100644",
+		"--- /dev/null",
+		"+++ b/add.go",
+		"@@ -0,0 +1 @@",
+		"+package main",
+		"",
+	}, "\n"), string(buf))
+	require.Len(t, p.patch.Files, 3)
+
+	comments, err := p.Comments(ctx)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	require.Equal(t, "main.go", comments[0].GetPath())
+	require.Equal(t, 3, comments[0].GetLine())
+
+	threads, err := p.Threads(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []*Thread{{
+		ID:        "d1",
+		CommentID: "11",
+		Path:      "main.go",
+		Body:      "issue\n\n<!-- golangci-lint-runner:abc -->",
+		LastBody:  "reply",
+	}}, threads)
+
+	err = p.CreateReview(ctx, &Review{
+		Body:  github.String("summary"),
+		Event: github.String(ReviewEventApprove),
+		Comments: []*ReviewComment{
+			{Path: github.String("main.go"), Body: github.String("added"), Line: github.Int(3)},
+			{Path: github.String("main.go"), Body: github.String("context"), Line: github.Int(4)},
+		},
+	})
+	require.NoError(t, err)
+	discussions := f.bodies["POST /projects/1/merge_requests/7/discussions"]
+	require.Len(t, discussions, 2)
+	require.Equal(t, map[string]interface{}{
+		"position_type": "text",
+		"base_sha":      "base",
+		"start_sha":     "start",
+		"head_sha":      "head",
+		"old_path":      "main.go",
+		"new_path":      "main.go",
+		"new_line":      float64(3),
+	}, discussions[0]["position"])
+	require.Equal(t, float64(3), discussions[1]["position"].(map[string]interface{})["old_line"])
+	require.Equal(t, "summary", f.bodies["POST /projects/1/merge_requests/7/notes"][0]["body"])
+	require.Len(t, f.bodies["POST /projects/1/merge_requests/7/approve"], 1)
+
+	err = p.CreateReview(ctx, &Review{Comments: []*ReviewComment{{Path: github.String("other.go"), Line: github.Int(1)}}})
+	require.Error(t, err)
+
+	require.NoError(t, p.ResolveThread(ctx, threads[0]))
+	require.NoError(t, p.ReplyToThread(ctx, threads[0], "outdated"))
+	require.NoError(t, p.SetStatus(ctx, StatusFailure, "failed"))
+	require.Equal(t, "failed", f.bodies["POST /projects/2/statuses/head"][0]["state"])
+	require.Contains(t, f.requests, "PUT /projects/1/merge_requests/7/discussions/d1")
+	require.Contains(t, f.requests, "POST /projects/1/merge_requests/7/discussions/d1/notes")
+
+	commit, err := p.DefaultBranchCommit(ctx, "group", ".gitlab")
+	require.NoError(t, err)
+	require.Equal(t, "abc", commit)
+	content, err := p.File(ctx, "group", ".gitlab", "golangci.yml", commit)
+	require.NoError(t, err)
+	require.Equal(t, "linters: {}\n", string(content))
+	_, err = p.File(ctx, "group", ".gitlab", "missing.yml", commit)
+	require.True(t, errors.Is(err, ErrNotFound), err)
+}
+
+func TestHandleGitLabEvent(t *testing.T) {
+	f := newFakeGitLab(t)
+	srv, err := NewServer(&ServerOptions{
+		QueueSize: 1,
+		GitLab: &GitLabOptions{
+			URL:           f.URL,
+			Token:         "secret",
+			WebhookSecret: "webhook",
+		},
+		Options: &Options{
+			Logger:   logger{},
+			CacheDir: t.TempDir(),
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		event  string
+		body   string
+		status int
+		queued int
+	}{
+		{name: "invalid token", token: "wrong", event: "Merge Request Hook", body: `{}`, status: http.StatusUnauthorized},
+		{name: "other event", token: "webhook", event: "Push Hook", body: `{}`, status: http.StatusOK},
+		{name: "title update", token: "webhook", event: "Merge Request Hook", body: `{"project":{"id":1},"object_attributes":{"iid":7,"action":"update"}}`, status: http.StatusOK},
+		{name: "open", token: "webhook", event: "Merge Request Hook", body: `{"project":{"id":1},"object_attributes":{"iid":7,"action":"open","target_project_id":1}}`, status: http.StatusOK, queued: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/gitlab", strings.NewReader(tt.body))
+			req.Header.Set("X-Gitlab-Token", tt.token)
+			req.Header.Set("X-Gitlab-Event", tt.event)
+			rec := httptest.NewRecorder()
+			srv.handler(srv.handleGitLabEvent)(rec, req)
+			res := rec.Result()
+			body, _ := ioutil.ReadAll(res.Body)
+			require.Equal(t, tt.status, res.StatusCode, string(body))
+			require.Len(t, srv.queue, tt.queued)
+		})
+	}
+}
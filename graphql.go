@@ -0,0 +1,58 @@
+package golangci_lint_runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQL runs a query (or mutation) against the github graphql api and unmarshals the data into v.
+func (p *GitHubProvider) graphQL(ctx context.Context, query string, variables map[string]interface{}, v interface{}) error {
+	req, err := p.client.NewRequest("POST", graphQLURL(p.client.BaseURL), graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	var res graphQLResponse
+	if _, err := p.client.Do(ctx, req, &res); err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		messages := make([]string, len(res.Errors))
+		for i, e := range res.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("graphql error: %s", strings.Join(messages, ", "))
+	}
+	if v == nil {
+		return nil
+	}
+	if len(res.Data) == 0 {
+		return errors.New("graphql response contains no data")
+	}
+	return json.Unmarshal(res.Data, v)
+}
+
+// graphQLURL returns the url of the graphql api for the rest api at baseURL,
+// https://api.github.com/graphql for github.com and https://github.example.com/api/graphql for GitHub Enterprise Server.
+func graphQLURL(baseURL *url.URL) string {
+	return baseURL.ResolveReference(&url.URL{Path: "../graphql"}).String()
+}
@@ -0,0 +1,381 @@
+package golangci_lint_runner
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golangci/golangci-lint/pkg/printers"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// The harness runs whole pull request flows offline: fakeGitHub serves the GitHub api (as GitHub Enterprise Server
+// under /api/v3), newGitServer serves fixture repositories over http for cloning and
+// installStubLinter puts a golangci-lint into the PATH that prints canned output.
+
+const (
+	harnessOwner             = "owner"
+	harnessRepo              = "repo"
+	harnessInstallationToken = "installation-token"
+)
+
+// fakeGitHub records the reviews and statuses that are posted.
+type fakeGitHub struct {
+	*httptest.Server
+	t *testing.T
+
+	mu sync.Mutex
+	// Diff of the pull request, Comments that were already posted and the Files of the repository by path@ref
+	Diff     string
+	Comments []*ReviewComment
+	Files    map[string]string
+	Reviews  []*Review
+	Statuses []string
+	// PullRequests that were opened
+	PullRequests []*github.NewPullRequest
+	// Threads are the review threads the graphql api returns, Mutations the graphql mutations that were run
+	// (e.g. resolveReviewThread T1) and Replies the comments that were posted as reply
+	Threads   []fakeThread
+	Mutations []string
+	Replies   []*github.PullRequestComment
+}
+
+// fakeThread is a review thread with its first comment.
+type fakeThread struct {
+	ID         string
+	CommentID  string
+	DatabaseID int64
+	Resolved   bool
+	Minimized  bool
+	Path       string
+	Body       string
+	LastBody   string
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	f := &fakeGitHub{t: t}
+	mux := http.NewServeMux()
+	repo := fmt.Sprintf("/api/v3/repos/%s/%s", harnessOwner, harnessRepo)
+	mux.HandleFunc("/api/v3/installations/", func(w http.ResponseWriter, r *http.Request) {
+		f.expect(r, http.MethodPost)
+		w.WriteHeader(http.StatusCreated)
+		f.reply(w, map[string]string{"token": harnessInstallationToken})
+	})
+	mux.HandleFunc(repo+"/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		f.expect(r, http.MethodGet)
+		require.Contains(t, r.Header.Get("Accept"), "diff")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		fmt.Fprint(w, f.Diff)
+	})
+	mux.HandleFunc(repo+"/pulls", func(w http.ResponseWriter, r *http.Request) {
+		f.expect(r, http.MethodPost)
+		var pr github.NewPullRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&pr))
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.PullRequests = append(f.PullRequests, &pr)
+		w.WriteHeader(http.StatusCreated)
+		f.reply(w, map[string]int{"number": len(f.PullRequests) + 1})
+	})
+	mux.HandleFunc(repo+"/pulls/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if r.Method == http.MethodGet {
+			f.reply(w, f.Comments)
+			return
+		}
+		f.expect(r, http.MethodPost)
+		var comment github.PullRequestComment
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&comment))
+		f.Replies = append(f.Replies, &comment)
+		w.WriteHeader(http.StatusCreated)
+		f.reply(w, comment)
+	})
+	mux.HandleFunc(repo+"/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		f.expect(r, http.MethodPost)
+		var review Review
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&review))
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.Reviews = append(f.Reviews, &review)
+		f.reply(w, map[string]int{"id": len(f.Reviews)})
+	})
+	mux.HandleFunc(repo+"/contents/", func(w http.ResponseWriter, r *http.Request) {
+		f.expect(r, http.MethodGet)
+		path := strings.TrimPrefix(r.URL.Path, repo+"/contents/")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		content, ok := f.Files[path+"@"+r.URL.Query().Get("ref")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		f.reply(w, &github.RepositoryContent{
+			Type:     github.String("file"),
+			Path:     github.String(path),
+			Encoding: github.String("base64"),
+			Content:  github.String(base64.StdEncoding.EncodeToString([]byte(content))),
+		})
+	})
+	mux.HandleFunc(repo+"/statuses/", func(w http.ResponseWriter, r *http.Request) {
+		f.expect(r, http.MethodPost)
+		var status github.RepoStatus
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.Statuses = append(f.Statuses, status.GetState())
+		w.WriteHeader(http.StatusCreated)
+		f.reply(w, status)
+	})
+	mux.HandleFunc("/api/graphql", func(w http.ResponseWriter, r *http.Request) {
+		f.expect(r, http.MethodPost)
+		var req graphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, mutation := range []string{"resolveReviewThread", "minimizeComment"} {
+			if strings.Contains(req.Query, mutation+"(") {
+				f.Mutations = append(f.Mutations, fmt.Sprintf("%s %s", mutation, req.Variables["id"]))
+				f.reply(w, map[string]interface{}{"data": map[string]interface{}{}})
+				return
+			}
+		}
+		f.reply(w, map[string]interface{}{"data": map[string]interface{}{"repository": map[string]interface{}{"pullRequest": map[string]interface{}{"reviewThreads": map[string]interface{}{
+			"pageInfo": map[string]interface{}{"hasNextPage": false},
+			"nodes":    f.threadNodes(),
+		}}}}})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		http.NotFound(w, r)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// threadNodes returns the Threads in the format of the reviewThreads query.
+func (f *fakeGitHub) threadNodes() []reviewThread {
+	nodes := make([]reviewThread, len(f.Threads))
+	for i, thread := range f.Threads {
+		nodes[i].ID = thread.ID
+		nodes[i].IsResolved = thread.Resolved
+		nodes[i].Comments.Nodes = []reviewThreadComment{{
+			ID:          thread.CommentID,
+			DatabaseID:  thread.DatabaseID,
+			Path:        thread.Path,
+			Body:        thread.Body,
+			IsMinimized: thread.Minimized,
+		}}
+		lastBody := thread.LastBody
+		if lastBody == "" {
+			lastBody = thread.Body
+		}
+		nodes[i].LastComment.Nodes = []reviewThreadComment{{Body: lastBody}}
+	}
+	return nodes
+}
+
+func (f *fakeGitHub) expect(r *http.Request, method string) {
+	require.Equal(f.t, method, r.Method, r.URL.String())
+}
+
+func (f *fakeGitHub) reply(w http.ResponseWriter, v interface{}) {
+	require.NoError(f.t, json.NewEncoder(w).Encode(v))
+}
+
+// review returns the only posted review.
+func (f *fakeGitHub) review() *Review {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.Len(f.t, f.Reviews, 1)
+	return f.Reviews[0]
+}
+
+// fixtureRepo is a repository with a base branch and a head branch with one more commit.
+type fixtureRepo struct {
+	Name string
+	Base plumbing.Hash
+	Head plumbing.Hash
+	// Diff from base to head
+	Diff string
+}
+
+// newFixtureRepo creates the repository name in root, head changes the base files.
+func newFixtureRepo(t *testing.T, root, name string, base, head map[string]string) *fixtureRepo {
+	dir := filepath.Join(root, name)
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commit := func(files map[string]string) plumbing.Hash {
+		for name, content := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0700))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+			_, err := worktree.Add(name)
+			require.NoError(t, err)
+		}
+		hash, err := worktree.Commit("commit", &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash
+	}
+
+	fixture := fixtureRepo{Name: name}
+	fixture.Base = commit(base)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/main", fixture.Base)))
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: "refs/heads/feature", Create: true}))
+	fixture.Head = commit(head)
+
+	baseCommit, err := repo.CommitObject(fixture.Base)
+	require.NoError(t, err)
+	headCommit, err := repo.CommitObject(fixture.Head)
+	require.NoError(t, err)
+	patch, err := baseCommit.Patch(headCommit)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, patch.Encode(&buf))
+	fixture.Diff = buf.String()
+	return &fixture
+}
+
+// newGitServer serves the repositories in root with git http-backend, clones and pushes must authenticate with token.
+func newGitServer(t *testing.T, root, token string) *httptest.Server {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	backend := &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		// http-backend only accepts pushes from authenticated users
+		Env: []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1", "REMOTE_USER=" + harnessOwner},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, ok := r.BasicAuth(); !ok || password != token {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// installStubLinter puts a golangci-lint into the PATH that prints the result for run and a version for --version.
+// A run with the fix config copies the fixes (content by path) into the repository instead.
+// It returns the file the arguments of the calls are appended to.
+func installStubLinter(t *testing.T, res *printers.JSONResult, fixes map[string]string) string {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output.json")
+	buf, err := json.Marshal(res)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(output, buf, 0600))
+
+	fixDir := filepath.Join(dir, "fixes")
+	require.NoError(t, os.Mkdir(fixDir, 0700))
+	for name, content := range fixes {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(fixDir, name)), 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(fixDir, name), []byte(content), 0600))
+	}
+
+	calls := filepath.Join(dir, "calls")
+	script := fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %q
+case "$*" in
+--version) echo "golangci-lint has version 1.30.0 built from stub on now" ;;
+run\ --config=*golangci-lint-fix.json) cp -R %q/. . ;;
+run*) cat %q ;;
+*) exit 3 ;;
+esac
+`, calls, fixDir, output)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "golangci-lint"), []byte(script), 0700))
+	// t.Setenv needs go 1.17
+	path := os.Getenv("PATH")
+	require.NoError(t, os.Setenv("PATH", dir+string(os.PathListSeparator)+path))
+	t.Cleanup(func() { _ = os.Setenv("PATH", path) })
+	return calls
+}
+
+// harnessPullRequest returns the pull request from the feature into the main branch of the fixture.
+func harnessPullRequest(cloneURL string, fixture *fixtureRepo) *github.PullRequest {
+	repo := &github.Repository{
+		Name:     github.String(harnessRepo),
+		FullName: github.String(harnessOwner + "/" + harnessRepo),
+		CloneURL: github.String(cloneURL + "/" + fixture.Name),
+		Owner:    &github.User{Login: github.String(harnessOwner)},
+	}
+	return &github.PullRequest{
+		Number:  github.Int(1),
+		HTMLURL: github.String("https://github.example.com/owner/repo/pull/1"),
+		Base:    &github.PullRequestBranch{Ref: github.String("main"), SHA: github.String(fixture.Base.String()), Repo: repo},
+		Head:    &github.PullRequestBranch{Ref: github.String("feature"), SHA: github.String(fixture.Head.String()), Repo: repo},
+	}
+}
+
+// sendWebhook posts the event signed with secret to the GitHub webhook of srv.
+func sendWebhook(t *testing.T, srv *Server, secret, eventType string, event interface{}) *httptest.ResponseRecorder {
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(payload)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	srv.handler(srv.handleEvent)(rec, req)
+	return rec
+}
+
+func newPrivateKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+// nextRunner takes the next job of the queue, like the queue worker does.
+func nextRunner(t *testing.T, srv *Server) *Runner {
+	select {
+	case runner := <-srv.queue:
+		return runner
+	default:
+		t.Fatal("no runner in queue")
+		return nil
+	}
+}
+
+func containsLine(s, line string) bool {
+	for _, l := range strings.Split(s, "\n") {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
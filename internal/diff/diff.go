@@ -0,0 +1,580 @@
+// Package diff parses unified diffs (as returned by the GitHub api) into files, hunks and lines.
+package diff
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LineKind describes whether a line was added, removed or left untouched.
+type LineKind int
+
+const (
+	Context LineKind = iota
+	Added
+	Removed
+)
+
+func (k LineKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return "context"
+	}
+}
+
+// Line is a single line inside a hunk.
+type Line struct {
+	Kind    LineKind
+	Content string
+	// OldLine is the line number in the old file, 0 for added lines
+	OldLine int
+	// NewLine is the line number in the new file, 0 for removed lines
+	NewLine int
+	// Position is the position relative to the first @@ in the file (what github calls position)
+	Position int
+	// NoNewlineAtEOF is set when the line is followed by "\ No newline at end of file"
+	NoNewlineAtEOF bool
+}
+
+// Hunk is a single @@ section of a file.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Section is the optional text after the closing @@
+	Section string
+	// Position is the position of the @@ header relative to the first @@ in the file
+	Position int
+	Lines    []Line
+}
+
+// File is a single file of a diff.
+// OldName is empty for new files, NewName is empty for deleted files.
+type File struct {
+	OldName   string
+	NewName   string
+	IsNew     bool
+	IsDeleted bool
+	IsRename  bool
+	IsCopy    bool
+	IsBinary  bool
+	Hunks     []*Hunk
+}
+
+// Name returns the name of the file after the change, or the old name if the file was deleted.
+func (f *File) Name() string {
+	if f.NewName != "" {
+		return f.NewName
+	}
+	return f.OldName
+}
+
+// AddedLines returns all lines that were added to the file.
+func (f *File) AddedLines() []Line {
+	var lines []Line
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind == Added {
+				lines = append(lines, l)
+			}
+		}
+	}
+	return lines
+}
+
+// LineAt returns the line with the specified line number in the new file.
+func (f *File) LineAt(newLine int) (Line, bool) {
+	h := f.HunkAt(newLine)
+	if h == nil {
+		return Line{}, false
+	}
+	for _, l := range h.Lines {
+		if l.NewLine == newLine {
+			return l, true
+		}
+	}
+	return Line{}, false
+}
+
+// HunkAt returns the hunk that contains the specified line number of the new file, nil if there is none.
+func (f *File) HunkAt(newLine int) *Hunk {
+	for _, h := range f.Hunks {
+		if newLine >= h.NewStart && newLine < h.NewStart+h.NewLines {
+			return h
+		}
+	}
+	return nil
+}
+
+// NewEnd returns the last line number of the hunk in the new file.
+func (h *Hunk) NewEnd() int {
+	return h.NewStart + h.NewLines - 1
+}
+
+// Diff is a parsed diff.
+type Diff struct {
+	Files []*File
+}
+
+// File returns the file with the specified name (after the change), nil if the file is not part of the diff.
+func (d *Diff) File(name string) *File {
+	for _, f := range d.Files {
+		if f.Name() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// ParseError is returned when the diff could not be parsed.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("diff: line %d: %s", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// ParseFile parses the diff stored in path.
+func ParseFile(path string) (*Diff, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses a unified diff, git extended headers (renames, binary files, modes) are supported.
+func Parse(r io.Reader) (*Diff, error) {
+	p := parser{
+		reader: bufio.NewReader(r),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return &Diff{Files: p.files}, nil
+}
+
+type parser struct {
+	reader *bufio.Reader
+	lineNo int
+	peeked *string
+	files  []*File
+	// current file and whether we already saw the ---/+++ header for it
+	file       *File
+	fileHeader bool
+	position   int
+}
+
+func (p *parser) errorf(format string, a ...interface{}) error {
+	return &ParseError{Line: p.lineNo, Err: fmt.Errorf(format, a...)}
+}
+
+// readLine returns a single line without the trailing newline, io.EOF is returned on the end of the input.
+func (p *parser) readLine() (string, error) {
+	if p.peeked != nil {
+		s := *p.peeked
+		p.peeked = nil
+		p.lineNo++
+		return s, nil
+	}
+	s, err := p.reader.ReadString('\n')
+	if err != nil {
+		if err != io.EOF || s == "" {
+			return "", err
+		}
+	}
+	p.lineNo++
+	return strings.TrimSuffix(s, "\n"), nil
+}
+
+func (p *parser) unreadLine(s string) {
+	p.peeked = &s
+	p.lineNo--
+}
+
+func (p *parser) newFile() *File {
+	p.file = &File{}
+	p.fileHeader = false
+	p.position = 0
+	p.files = append(p.files, p.file)
+	return p.file
+}
+
+func (p *parser) parse() error {
+	for {
+		line, err := p.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			if err := p.parseGitHeader(line); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "--- "):
+			if err := p.parseFileHeader(line); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "@@ "):
+			if err := p.parseHunk(line); err != nil {
+				return err
+			}
+		default:
+			// everything else (e.g. preambles, "Only in ...") carries no information for us
+		}
+	}
+}
+
+func (p *parser) parseGitHeader(line string) error {
+	f := p.newFile()
+	oldName, newName, ok := splitGitNames(strings.TrimPrefix(line, "diff --git "))
+	if !ok {
+		return p.errorf("unable to parse file names in %q", line)
+	}
+	f.OldName = oldName
+	f.NewName = newName
+
+	for {
+		line, err := p.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case strings.HasPrefix(line, "new file mode "):
+			f.IsNew = true
+		case strings.HasPrefix(line, "deleted file mode "):
+			f.IsDeleted = true
+		case strings.HasPrefix(line, "rename from "):
+			f.IsRename = true
+			if f.OldName, err = unquote(strings.TrimPrefix(line, "rename from ")); err != nil {
+				return p.errorf("%w", err)
+			}
+		case strings.HasPrefix(line, "rename to "):
+			f.IsRename = true
+			if f.NewName, err = unquote(strings.TrimPrefix(line, "rename to ")); err != nil {
+				return p.errorf("%w", err)
+			}
+		case strings.HasPrefix(line, "copy from "):
+			f.IsCopy = true
+			if f.OldName, err = unquote(strings.TrimPrefix(line, "copy from ")); err != nil {
+				return p.errorf("%w", err)
+			}
+		case strings.HasPrefix(line, "copy to "):
+			f.IsCopy = true
+			if f.NewName, err = unquote(strings.TrimPrefix(line, "copy to ")); err != nil {
+				return p.errorf("%w", err)
+			}
+		case strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+			f.IsBinary = true
+		case strings.HasPrefix(line, "old mode "),
+			strings.HasPrefix(line, "new mode "),
+			strings.HasPrefix(line, "index "),
+			strings.HasPrefix(line, "similarity index "),
+			strings.HasPrefix(line, "dissimilarity index "):
+		default:
+			// end of the extended header (---, @@, the next diff or binary data)
+			if f.IsBinary && !strings.HasPrefix(line, "diff --git ") {
+				// skip binary patch data
+				continue
+			}
+			p.unreadLine(line)
+			p.fixupNames()
+			return nil
+		}
+	}
+	p.fixupNames()
+	return nil
+}
+
+// fixupNames clears the names for new and deleted files.
+func (p *parser) fixupNames() {
+	if p.file.IsNew {
+		p.file.OldName = ""
+	}
+	if p.file.IsDeleted {
+		p.file.NewName = ""
+	}
+}
+
+func (p *parser) parseFileHeader(line string) error {
+	// a "--- " without a preceding "diff --git" (or a second one) starts a new (plain unified diff) file
+	if p.file == nil || p.fileHeader || len(p.file.Hunks) > 0 {
+		p.newFile()
+	}
+	f := p.file
+	p.fileHeader = true
+
+	oldName, err := parseHeaderName(strings.TrimPrefix(line, "--- "), "a/")
+	if err != nil {
+		return p.errorf("%w", err)
+	}
+
+	line, err = p.readLine()
+	if err == io.EOF {
+		return p.errorf("unexpected end of diff, expected +++ header")
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "+++ ") {
+		return p.errorf("expected +++ header, got %q", line)
+	}
+	newName, err := parseHeaderName(strings.TrimPrefix(line, "+++ "), "b/")
+	if err != nil {
+		return p.errorf("%w", err)
+	}
+
+	f.OldName = oldName
+	f.NewName = newName
+	if oldName == "" {
+		f.IsNew = true
+	}
+	if newName == "" {
+		f.IsDeleted = true
+	}
+	return nil
+}
+
+func (p *parser) parseHunk(line string) error {
+	if p.file == nil {
+		return p.errorf("hunk header %q without file header", line)
+	}
+	h, err := parseHunkHeader(line)
+	if err != nil {
+		return p.errorf("%w", err)
+	}
+	if len(p.file.Hunks) > 0 {
+		p.position++
+	}
+	h.Position = p.position
+	p.file.Hunks = append(p.file.Hunks, h)
+
+	oldLine, newLine := h.OldStart, h.NewStart
+	oldRemaining, newRemaining := h.OldLines, h.NewLines
+
+	for oldRemaining > 0 || newRemaining > 0 {
+		line, err := p.readLine()
+		if err == io.EOF {
+			return p.errorf("unexpected end of diff in hunk %q", h.header())
+		}
+		if err != nil {
+			return err
+		}
+		p.position++
+
+		if line == "" {
+			// some tools strip the trailing space of empty context lines
+			line = " "
+		}
+
+		l := Line{
+			Content:  line[1:],
+			Position: p.position,
+		}
+
+		switch line[0] {
+		case ' ':
+			if oldRemaining == 0 || newRemaining == 0 {
+				return p.errorf("unexpected context line in hunk %q", h.header())
+			}
+			l.Kind = Context
+			l.OldLine = oldLine
+			l.NewLine = newLine
+			oldLine++
+			newLine++
+			oldRemaining--
+			newRemaining--
+		case '-':
+			if oldRemaining == 0 {
+				return p.errorf("unexpected removed line in hunk %q", h.header())
+			}
+			l.Kind = Removed
+			l.OldLine = oldLine
+			oldLine++
+			oldRemaining--
+		case '+':
+			if newRemaining == 0 {
+				return p.errorf("unexpected added line in hunk %q", h.header())
+			}
+			l.Kind = Added
+			l.NewLine = newLine
+			newLine++
+			newRemaining--
+		case '\\':
+			if len(h.Lines) == 0 {
+				return p.errorf("unexpected %q at the beginning of hunk %q", line, h.header())
+			}
+			h.Lines[len(h.Lines)-1].NoNewlineAtEOF = true
+			continue
+		default:
+			return p.errorf("invalid line %q in hunk %q", line, h.header())
+		}
+		h.Lines = append(h.Lines, l)
+	}
+
+	// the "\ No newline at end of file" marker can follow the last line of a hunk
+	line, err = p.readLine()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(line, `\`) && len(h.Lines) > 0 {
+		p.position++
+		h.Lines[len(h.Lines)-1].NoNewlineAtEOF = true
+		return nil
+	}
+	p.unreadLine(line)
+	return nil
+}
+
+func (h *Hunk) header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// parseHunkHeader parses headers like "@@ -1,2 +3,4 @@ func main() {", omitted counts default to 1.
+func parseHunkHeader(line string) (*Hunk, error) {
+	rest := strings.TrimPrefix(line, "@@ ")
+	end := strings.Index(rest, " @@")
+	if end < 0 {
+		return nil, fmt.Errorf("invalid hunk header %q", line)
+	}
+	ranges := strings.Fields(rest[:end])
+	if len(ranges) != 2 || !strings.HasPrefix(ranges[0], "-") || !strings.HasPrefix(ranges[1], "+") {
+		return nil, fmt.Errorf("invalid hunk header %q", line)
+	}
+
+	var h Hunk
+	var err error
+	if h.OldStart, h.OldLines, err = parseRange(ranges[0][1:]); err != nil {
+		return nil, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	if h.NewStart, h.NewLines, err = parseRange(ranges[1][1:]); err != nil {
+		return nil, fmt.Errorf("invalid hunk header %q: %w", line, err)
+	}
+	h.Section = strings.TrimPrefix(rest[end+len(" @@"):], " ")
+	return &h, nil
+}
+
+func parseRange(s string) (start, count int, err error) {
+	count = 1
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		if count, err = strconv.Atoi(s[i+1:]); err != nil {
+			return 0, 0, err
+		}
+		s = s[:i]
+	}
+	if start, err = strconv.Atoi(s); err != nil {
+		return 0, 0, err
+	}
+	if start < 0 || count < 0 {
+		return 0, 0, errors.New("negative range")
+	}
+	return start, count, nil
+}
+
+// parseHeaderName parses the name of a ---/+++ line, /dev/null results in an empty name.
+func parseHeaderName(s, prefix string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		// plain diffs might append a timestamp separated by a tab
+		if i := strings.IndexByte(s, '\t'); i >= 0 {
+			s = s[:i]
+		}
+		s = strings.TrimRight(s, " ")
+	}
+	name, err := unquote(s)
+	if err != nil {
+		return "", err
+	}
+	if name == "/dev/null" {
+		return "", nil
+	}
+	return strings.TrimPrefix(name, prefix), nil
+}
+
+// splitGitNames splits the names of a "diff --git a/x b/y" line.
+func splitGitNames(s string) (string, string, bool) {
+	if strings.HasPrefix(s, `"`) || strings.HasSuffix(s, `"`) {
+		oldName, rest, ok := cutQuoted(s)
+		if !ok {
+			return "", "", false
+		}
+		newName, err := unquote(strings.TrimPrefix(rest, " "))
+		if err != nil {
+			return "", "", false
+		}
+		return strings.TrimPrefix(oldName, "a/"), strings.TrimPrefix(newName, "b/"), true
+	}
+
+	// names are equal in most cases (not renamed), which is the only case we can split unambiguous
+	if len(s)%2 == 1 {
+		mid := len(s) / 2
+		if s[mid] == ' ' && strings.TrimPrefix(s[:mid], "a/") == strings.TrimPrefix(s[mid+1:], "b/") {
+			return strings.TrimPrefix(s[:mid], "a/"), strings.TrimPrefix(s[mid+1:], "b/"), true
+		}
+	}
+	// renames are resolved by the rename from/to or ---/+++ lines
+	if i := strings.Index(s, " b/"); i >= 0 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+len(" b/"):], true
+	}
+	return "", "", false
+}
+
+// cutQuoted splits s into its first (possibly quoted) name and the remainder.
+func cutQuoted(s string) (string, string, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		i := strings.IndexByte(s, ' ')
+		if i < 0 {
+			return "", "", false
+		}
+		return s[:i], s[i:], true
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			name, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", false
+			}
+			return name, s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// unquote removes git's c-style quoting of file names.
+func unquote(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+	name, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid quoted name %s: %w", s, err)
+	}
+	return name, nil
+}
@@ -0,0 +1,346 @@
+package diff
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ctx(oldLine, newLine, pos int, content string) Line {
+	return Line{Kind: Context, Content: content, OldLine: oldLine, NewLine: newLine, Position: pos}
+}
+
+func add(newLine, pos int, content string) Line {
+	return Line{Kind: Added, Content: content, NewLine: newLine, Position: pos}
+}
+
+func del(oldLine, pos int, content string) Line {
+	return Line{Kind: Removed, Content: content, OldLine: oldLine, Position: pos}
+}
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect *Diff
+	}{
+		{
+			name: "modified.diff",
+			expect: &Diff{Files: []*File{
+				{
+					OldName: "runner.go",
+					NewName: "runner.go",
+					Hunks: []*Hunk{
+						{
+							OldStart: 1, OldLines: 6, NewStart: 1, NewLines: 7,
+							Position: 0,
+							Lines: []Line{
+								ctx(1, 1, 1, "package golangci_lint_runner"),
+								ctx(2, 2, 2, ""),
+								ctx(3, 3, 3, "import ("),
+								add(4, 4, "\t\"bytes\""),
+								ctx(4, 5, 5, "\t\"fmt\""),
+								ctx(5, 6, 6, ""),
+								ctx(6, 7, 7, "\t\"context\""),
+							},
+						},
+						{
+							OldStart: 20, OldLines: 7, NewStart: 21, NewLines: 7,
+							Section:  "type Options struct {",
+							Position: 8,
+							Lines: []Line{
+								ctx(20, 21, 9, "\tClient            *github.Client"),
+								ctx(21, 22, 10, "\tCloneToken        string"),
+								del(22, 11, "\tContext           context.Context"),
+								add(23, 12, "\tCtx               context.Context"),
+								ctx(23, 24, 13, "\tPullRequest       *github.PullRequest"),
+								ctx(24, 25, 14, "\tName              string"),
+								ctx(25, 26, 15, "\tOwner             string"),
+								ctx(26, 27, 16, "\tLogger            Logger"),
+							},
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "new_deleted.diff",
+			expect: &Diff{Files: []*File{
+				{
+					NewName: "internal/wire_error.go",
+					IsNew:   true,
+					Hunks: []*Hunk{
+						{
+							OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 3,
+							Lines: []Line{
+								add(1, 1, "package internal"),
+								add(2, 2, ""),
+								add(3, 3, "type WireError struct{}"),
+							},
+						},
+					},
+				},
+				{
+					OldName:   "dummy_logger.go",
+					IsDeleted: true,
+					Hunks: []*Hunk{
+						{
+							OldStart: 1, OldLines: 2, NewStart: 0, NewLines: 0,
+							Lines: []Line{
+								del(1, 1, "package golangci_lint_runner"),
+								del(2, 2, ""),
+							},
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "rename.diff",
+			expect: &Diff{Files: []*File{
+				{
+					OldName:  "old.go",
+					NewName:  "new.go",
+					IsRename: true,
+				},
+				{
+					OldName:  "pkg/a.go",
+					NewName:  "pkg/b.go",
+					IsRename: true,
+					Hunks: []*Hunk{
+						{
+							OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3,
+							Lines: []Line{
+								ctx(1, 1, 1, "package pkg"),
+								ctx(2, 2, 2, ""),
+								del(3, 3, "func A() {}"),
+								add(3, 4, "func B() {}"),
+							},
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "binary.diff",
+			expect: &Diff{Files: []*File{
+				{
+					NewName:  "logo.png",
+					IsNew:    true,
+					IsBinary: true,
+				},
+				{
+					OldName:  "icon.ico",
+					NewName:  "icon.ico",
+					IsBinary: true,
+				},
+				{
+					OldName: "main.go",
+					NewName: "main.go",
+					Hunks: []*Hunk{
+						{
+							OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 2,
+							Lines: []Line{
+								ctx(1, 1, 1, "package main"),
+								add(2, 2, "// Main"),
+							},
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "no_newline.diff",
+			expect: &Diff{Files: []*File{
+				{
+					OldName: "README.md",
+					NewName: "README.md",
+					Hunks: []*Hunk{
+						{
+							OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2,
+							Lines: []Line{
+								ctx(1, 1, 1, "# golangci-lint-runner"),
+								{Kind: Removed, Content: "old line", OldLine: 2, Position: 2, NoNewlineAtEOF: true},
+								{Kind: Added, Content: "new line", NewLine: 2, Position: 4, NoNewlineAtEOF: true},
+							},
+						},
+					},
+				},
+				{
+					OldName: "main.go",
+					NewName: "main.go",
+					Hunks: []*Hunk{
+						{
+							OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 2,
+							Lines: []Line{
+								ctx(1, 1, 1, "package main"),
+								add(2, 2, "func main() {}"),
+							},
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "tricky.diff",
+			expect: &Diff{Files: []*File{
+				{
+					OldName: "sql/schema.sql",
+					NewName: "sql/schema.sql",
+					Hunks: []*Hunk{
+						{
+							OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3,
+							Lines: []Line{
+								ctx(1, 1, 1, "CREATE TABLE a (id INT);"),
+								del(2, 2, "-- drop this comment"),
+								add(2, 3, "++ counter"),
+								ctx(3, 3, 4, "CREATE TABLE b (id INT);"),
+							},
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "quoted.diff",
+			expect: &Diff{Files: []*File{
+				{
+					OldName: "docs/my file.go",
+					NewName: "docs/my file.go",
+					Hunks: []*Hunk{
+						{
+							OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1,
+							Lines: []Line{
+								del(1, 1, "package docs"),
+								add(1, 2, `package docs // \303\244`),
+							},
+						},
+					},
+				},
+				{
+					NewName: "ä.go",
+					IsNew:   true,
+					Hunks: []*Hunk{
+						{
+							OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1,
+							Lines: []Line{
+								add(1, 1, "package main"),
+							},
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "plain.diff",
+			expect: &Diff{Files: []*File{
+				{
+					OldName: "main.go",
+					NewName: "main.go",
+					Hunks: []*Hunk{
+						{
+							OldStart: 3, OldLines: 4, NewStart: 3, NewLines: 5,
+							Lines: []Line{
+								ctx(3, 3, 1, `import "fmt"`),
+								ctx(4, 4, 2, ""),
+								ctx(5, 5, 3, "func main() {"),
+								add(6, 4, "\tfmt.Println(\"hello\")"),
+								ctx(6, 7, 5, "}"),
+							},
+						},
+					},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseFile(filepath.Join("testdata", tt.name))
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, d)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		line  int
+	}{
+		{
+			name:  "hunk without file",
+			patch: "@@ -1 +1 @@\n-a\n+b\n",
+			line:  1,
+		},
+		{
+			name:  "invalid hunk header",
+			patch: "--- a/a.go\n+++ b/a.go\n@@ -1,x +1 @@\n-a\n+b\n",
+			line:  3,
+		},
+		{
+			name:  "hunk header without closing @@",
+			patch: "--- a/a.go\n+++ b/a.go\n@@ -1 +1\n-a\n+b\n",
+			line:  3,
+		},
+		{
+			name:  "missing +++",
+			patch: "--- a/a.go\n@@ -1 +1 @@\n-a\n+b\n",
+			line:  2,
+		},
+		{
+			name:  "truncated hunk",
+			patch: "--- a/a.go\n+++ b/a.go\n@@ -1,3 +1,3 @@\n a\n-b\n",
+			line:  5,
+		},
+		{
+			name:  "too many added lines",
+			patch: "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n+a\n+b\n",
+			line:  5,
+		},
+		{
+			name:  "invalid line in hunk",
+			patch: "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n?a\n",
+			line:  4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.patch))
+			require.Error(t, err)
+			var parseError *ParseError
+			require.True(t, errors.As(err, &parseError), "expected ParseError, got %T", err)
+			require.Equal(t, tt.line, parseError.Line)
+		})
+	}
+}
+
+func TestFile_LineAt(t *testing.T) {
+	d, err := ParseFile(filepath.Join("testdata", "modified.diff"))
+	require.NoError(t, err)
+	f := d.File("runner.go")
+	require.NotNil(t, f)
+
+	l, ok := f.LineAt(23)
+	require.True(t, ok)
+	require.Equal(t, add(23, 12, "\tCtx               context.Context"), l)
+
+	l, ok = f.LineAt(5)
+	require.True(t, ok)
+	require.Equal(t, Context, l.Kind)
+	require.Equal(t, 4, l.OldLine)
+
+	_, ok = f.LineAt(15)
+	require.False(t, ok)
+
+	h := f.HunkAt(27)
+	require.NotNil(t, h)
+	require.Equal(t, 21, h.NewStart)
+	require.Equal(t, 27, h.NewEnd())
+	require.Nil(t, f.HunkAt(28))
+
+	require.Len(t, f.AddedLines(), 2)
+	require.Nil(t, d.File("unknown.go"))
+}
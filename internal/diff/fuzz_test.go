@@ -0,0 +1,60 @@
+//go:build go1.18
+// +build go1.18
+
+package diff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func FuzzParse(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.diff"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, file := range files {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf)
+	}
+
+	f.Fuzz(func(t *testing.T, patch []byte) {
+		d, err := Parse(bytes.NewReader(patch))
+		if err != nil {
+			return
+		}
+		for _, file := range d.Files {
+			lastPosition := -1
+			for _, h := range file.Hunks {
+				if h.Position <= lastPosition {
+					t.Fatalf("hunk position %d is not increasing in %s", h.Position, file.Name())
+				}
+				lastPosition = h.Position
+				var oldLines, newLines int
+				for _, l := range h.Lines {
+					if l.Position <= lastPosition {
+						t.Fatalf("line position %d is not increasing in %s", l.Position, file.Name())
+					}
+					lastPosition = l.Position
+					switch l.Kind {
+					case Context:
+						oldLines++
+						newLines++
+					case Added:
+						newLines++
+					case Removed:
+						oldLines++
+					}
+				}
+				if oldLines != h.OldLines || newLines != h.NewLines {
+					t.Fatalf("hunk %s contains %d old and %d new lines", h.header(), oldLines, newLines)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type checkstyleOutput struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func writeCheckstyle(w io.Writer, r *Report) error {
+	out := checkstyleOutput{Version: "5.0"}
+	index := make(map[string]int)
+	for _, name := range files(r.Issues) {
+		index[name] = len(out.Files)
+		out.Files = append(out.Files, checkstyleFile{Name: name})
+	}
+	for i := range r.Issues {
+		issue := &r.Issues[i]
+		file := &out.Files[index[issue.FilePath()]]
+		file.Errors = append(file.Errors, checkstyleError{
+			Line:     issue.Line(),
+			Column:   issue.Column(),
+			Severity: issue.Severity,
+			Message:  issue.Text,
+			Source:   issue.FromLinter,
+		})
+	}
+	return writeXML(w, out)
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
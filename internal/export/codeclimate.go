@@ -0,0 +1,63 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+type codeClimateIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Categories  []string            `json:"categories"`
+	Severity    string              `json:"severity"`
+	Fingerprint string              `json:"fingerprint,omitempty"`
+	Location    codeClimateLocation `json:"location"`
+}
+
+type codeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines codeClimateLines `json:"lines"`
+}
+
+type codeClimateLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end"`
+}
+
+func codeClimateSeverity(severity string) string {
+	switch severity {
+	case SeverityWarning:
+		return "minor"
+	case SeverityInfo:
+		return "info"
+	default:
+		return "major"
+	}
+}
+
+// writeCodeClimate writes the issues in the code climate format (as used by gitlab code quality reports).
+func writeCodeClimate(w io.Writer, r *Report) error {
+	out := make([]codeClimateIssue, 0, len(r.Issues))
+	for i := range r.Issues {
+		issue := &r.Issues[i]
+		lines := issue.GetLineRange()
+		out = append(out, codeClimateIssue{
+			Type:        "issue",
+			CheckName:   issue.FromLinter,
+			Description: fmt.Sprintf("%s: %s", issue.FromLinter, issue.Text),
+			Categories:  []string{"Style"},
+			Severity:    codeClimateSeverity(issue.Severity),
+			Fingerprint: issue.Fingerprint,
+			Location: codeClimateLocation{
+				Path:  filepath.ToSlash(issue.FilePath()),
+				Lines: codeClimateLines{Begin: lines.From, End: lines.To},
+			},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
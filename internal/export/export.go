@@ -0,0 +1,102 @@
+// Package export writes issues in machine readable formats for CI systems and code scanning dashboards.
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+)
+
+// Formats that can be written.
+const (
+	FormatSARIF       = "sarif"
+	FormatCheckstyle  = "checkstyle"
+	FormatJUnit       = "junit"
+	FormatCodeClimate = "codeclimate"
+)
+
+// Severities of issues, Issue.Severity must be one of them.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+var writers = map[string]func(io.Writer, *Report) error{
+	FormatSARIF:       writeSARIF,
+	FormatCheckstyle:  writeCheckstyle,
+	FormatJUnit:       writeJUnit,
+	FormatCodeClimate: writeCodeClimate,
+}
+
+// Issue is an issue with a stable fingerprint.
+type Issue struct {
+	result.Issue
+	Fingerprint string
+}
+
+// Report is the data that is written.
+type Report struct {
+	Issues []Issue
+	// Version of golangci-lint, optional
+	Version string
+}
+
+// Formats returns all supported formats.
+func Formats() []string {
+	formats := make([]string, 0, len(writers))
+	for format := range writers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// IsFormat reports whether the format is supported.
+func IsFormat(format string) bool {
+	_, ok := writers[format]
+	return ok
+}
+
+// Write writes the report in the format to w.
+func Write(w io.Writer, format string, r *Report) error {
+	write, ok := writers[format]
+	if !ok {
+		return fmt.Errorf("unknown report format %q", format)
+	}
+	return write(w, r)
+}
+
+// WriteFile writes the report in the format to the file.
+func WriteFile(path, format string, r *Report) error {
+	if !IsFormat(format) {
+		return fmt.Errorf("unknown report format %q", format)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, format, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// files returns the files of the issues in the order they appear.
+func files(issues []Issue) []string {
+	var files []string
+	seen := make(map[string]struct{})
+	for i := range issues {
+		path := issues[i].FilePath()
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		files = append(files, path)
+	}
+	return files
+}
@@ -0,0 +1,82 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+var testReport = &Report{
+	Version: "1.30.0",
+	Issues: []Issue{
+		{
+			Issue: result.Issue{
+				FromLinter:  "errcheck",
+				Text:        "Error return value of `f.Close` is not checked",
+				Severity:    SeverityError,
+				SourceLines: []string{"\tf.Close()"},
+				Pos:         token.Position{Filename: "main.go", Line: 10, Column: 9},
+			},
+			Fingerprint: "0123456789abcdef",
+		},
+		{
+			Issue: result.Issue{
+				FromLinter:  "misspell",
+				Text:        "`recieve` is a misspelling of `receive`",
+				Severity:    SeverityWarning,
+				SourceLines: []string{"// recieve <data> & \"more\""},
+				Pos:         token.Position{Filename: "pkg/a.go", Line: 3, Column: 4},
+			},
+			Fingerprint: "fedcba9876543210",
+		},
+		{
+			Issue: result.Issue{
+				FromLinter:  "dupl",
+				Text:        "lines 20-22 are duplicate",
+				Severity:    SeverityInfo,
+				SourceLines: []string{"func a() {", "\treturn", "}"},
+				Pos:         token.Position{Filename: "main.go", Line: 20},
+				LineRange:   &result.Range{From: 20, To: 22},
+			},
+		},
+	},
+}
+
+func TestWrite(t *testing.T) {
+	for _, format := range Formats() {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, format, testReport))
+
+			golden := filepath.Join("testdata", format)
+			if *update {
+				require.NoError(t, ioutil.WriteFile(golden, buf.Bytes(), 0644))
+			}
+			expect, err := ioutil.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(expect), buf.String())
+		})
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	for _, format := range Formats() {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, format, &Report{}))
+			require.NotEmpty(t, buf.String())
+		})
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	require.EqualError(t, Write(ioutil.Discard, "html", testReport), `unknown report format "html"`)
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	ClassName string       `xml:"classname,attr"`
+	Failure   junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",cdata"`
+}
+
+// writeJUnit writes a test suite per file with a failed test case per issue.
+func writeJUnit(w io.Writer, r *Report) error {
+	out := junitTestSuites{TestSuites: []junitTestSuite{}}
+	index := make(map[string]int)
+	for _, name := range files(r.Issues) {
+		index[name] = len(out.TestSuites)
+		out.TestSuites = append(out.TestSuites, junitTestSuite{Name: name})
+	}
+	for i := range r.Issues {
+		issue := &r.Issues[i]
+		suite := &out.TestSuites[index[issue.FilePath()]]
+		suite.Tests++
+		suite.Failures++
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      issue.FromLinter,
+			ClassName: fmt.Sprintf("%s:%d", issue.FilePath(), issue.Line()),
+			Failure: junitFailure{
+				Message: issue.Text,
+				Type:    issue.Severity,
+				Content: strings.Join(issue.SourceLines, "\n"),
+			},
+		})
+	}
+	return writeXML(w, out)
+}
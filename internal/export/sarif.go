@@ -0,0 +1,144 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// sarifFingerprint is the key of our fingerprint in partialFingerprints
+	sarifFingerprint = "golangciLintRunner/v1"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+func writeSARIF(w io.Writer, r *Report) error {
+	driver := sarifDriver{
+		Name:           "golangci-lint",
+		InformationURI: "https://github.com/golangci/golangci-lint",
+		Version:        r.Version,
+		Rules:          []sarifRule{},
+	}
+
+	rules := make(map[string]int)
+	for i := range r.Issues {
+		rules[r.Issues[i].FromLinter] = 0
+	}
+	for id := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{ID: id})
+	}
+	sort.Slice(driver.Rules, func(i, j int) bool {
+		return driver.Rules[i].ID < driver.Rules[j].ID
+	})
+	for i, rule := range driver.Rules {
+		rules[rule.ID] = i
+	}
+
+	results := make([]sarifResult, 0, len(r.Issues))
+	for i := range r.Issues {
+		issue := &r.Issues[i]
+		lines := issue.GetLineRange()
+		region := sarifRegion{
+			StartLine:   lines.From,
+			StartColumn: issue.Column(),
+		}
+		if lines.To > lines.From {
+			region.EndLine = lines.To
+		}
+		res := sarifResult{
+			RuleID:    issue.FromLinter,
+			RuleIndex: rules[issue.FromLinter],
+			Level:     sarifLevel(issue.Severity),
+			Message:   sarifMessage{Text: issue.Text},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(issue.FilePath())},
+					Region:           region,
+				},
+			}},
+		}
+		if issue.Fingerprint != "" {
+			res.PartialFingerprints = map[string]string{sarifFingerprint: issue.Fingerprint}
+		}
+		results = append(results, res)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
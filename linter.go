@@ -3,22 +3,23 @@ package golangci_lint_runner
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os/exec"
+	"regexp"
 
 	"os"
 
-	"bufio"
-	"io"
-	"strconv"
 	"strings"
 
 	"path/filepath"
 
 	"errors"
 
+	"github.com/golangci/golangci-lint/pkg/config"
 	"github.com/golangci/golangci-lint/pkg/printers"
 	"github.com/golangci/golangci-lint/pkg/result"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/talon-one/golangci-lint-runner/internal/diff"
 )
 
 func (runner *Runner) runLinter(cacheDir, workDir, repoDir string) (*printers.JSONResult, error) {
@@ -27,7 +28,88 @@ func (runner *Runner) runLinter(cacheDir, workDir, repoDir string) (*printers.JS
 		return nil, err
 	}
 
-	cmd := exec.Command("golangci-lint", "run", "--config="+configPath)
+	out, err := runner.execLinter(cacheDir, workDir, repoDir, "run", "--config="+configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var res printers.JSONResult
+	err = json.Unmarshal(out, &res)
+	if err != nil {
+		return nil, fmt.Errorf("can't run golangci-lint: invalid output json: %s, %w", string(out), err)
+	}
+
+	if res.Report != nil && res.Report.Error != "" {
+		return nil, fmt.Errorf("can't run golangci-lint: %s", res.Report.Error)
+	}
+
+	return &res, nil
+}
+
+// LinterVersionFile is the file in the root of a repository that can contain the golangci-lint version the
+// repository requires, the golangci-lint-runner.version setting of the config takes precedence.
+const LinterVersionFile = ".golangci-lint-version"
+
+// linterVersionKey is the key of the required golangci-lint version in a config file.
+const linterVersionKey = "golangci-lint-runner.version"
+
+var linterVersionRegexp = regexp.MustCompile(`^v?([0-9]+(\.[0-9]+){0,2})$`)
+
+// selectLinter selects the golangci-lint binary of the version the repository requires from Options.LinterDir,
+// the golangci-lint of the PATH is used if no version is required or the version is not installed.
+// It returns the required version if it is not available.
+func (runner *Runner) selectLinter(repoDir string) (unavailable string) {
+	version := runner.requiredVersion
+	if version == "" {
+		buf, err := runner.readVersionFile(repoDir)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				runner.Options.Logger.Warn("unable to read %s: %s", LinterVersionFile, err)
+			}
+			return ""
+		}
+		version = strings.TrimSpace(string(buf))
+	}
+	if version == "" {
+		return ""
+	}
+
+	m := linterVersionRegexp.FindStringSubmatch(version)
+	if m == nil {
+		runner.Options.Logger.Warn("invalid golangci-lint version %q, using the default version", version)
+		return version
+	}
+	if runner.Options.LinterDir != "" {
+		p := filepath.Join(runner.Options.LinterDir, m[1], "golangci-lint")
+		if info, err := os.Stat(p); err == nil && info.Mode().IsRegular() {
+			runner.Options.Logger.Debug("using golangci-lint %s", p)
+			runner.linterPath = p
+			return ""
+		}
+	}
+	runner.Options.Logger.Warn("golangci-lint %s is not available, using the default version", version)
+	return version
+}
+
+// readVersionFile reads the LinterVersionFile from the same source as the linter config, it returns an error
+// wrapping ErrNotFound if the file does not exist.
+func (runner *Runner) readVersionFile(repoDir string) ([]byte, error) {
+	if runner.configSource() == ConfigSourceBase {
+		return runner.baseFile(LinterVersionFile)
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(repoDir, LinterVersionFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("file %s: %w", LinterVersionFile, ErrNotFound)
+	}
+	return buf, err
+}
+
+func (runner *Runner) execLinter(cacheDir, workDir, repoDir string, args ...string) ([]byte, error) {
+	name := runner.linterPath
+	if name == "" {
+		name = "golangci-lint"
+	}
+	cmd := exec.Command(name, args...)
 	cmd.Dir = repoDir
 	cmd.Env = []string{
 		"PATH=" + os.Getenv("PATH"),
@@ -58,27 +140,11 @@ func (runner *Runner) runLinter(cacheDir, workDir, repoDir string) (*printers.JS
 
 		return nil, fmt.Errorf("golangci-lint got error: %w", err)
 	}
-
-	var res printers.JSONResult
-	err = json.Unmarshal(out, &res)
-	if err != nil {
-		return nil, fmt.Errorf("can't run golangci-lint: invalid output json: %s, %w", string(out), err)
-	}
-
-	if res.Report != nil && res.Report.Error != "" {
-		return nil, fmt.Errorf("can't run golangci-lint: %w", res.Report.Error)
-	}
-
-	return &res, nil
+	return out, nil
 }
 
 func (runner *Runner) generateConfig(workDir string) (string, error) {
 	configPath := filepath.Join(workDir, "golangci-lint.json")
-	file, err := os.Create(configPath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
 
 	runner.Options.LinterConfig.Run.IsVerbose = false
 	runner.Options.LinterConfig.Run.Silent = false
@@ -125,6 +191,16 @@ func (runner *Runner) generateConfig(workDir string) (string, error) {
 
 	//runner.Options.LinterConfig.Issues.NeedFix -- use parent
 
+	return configPath, writeConfig(configPath, &runner.Options.LinterConfig)
+}
+
+func writeConfig(configPath string, cfg *config.Config) error {
+	file, err := os.Create(configPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
 	var json = jsoniter.Config{
 		EscapeHTML:             true,
 		SortMapKeys:            true,
@@ -132,137 +208,55 @@ func (runner *Runner) generateConfig(workDir string) (string, error) {
 		TagKey:                 "mapstructure",
 	}.Froze()
 
-	return configPath, json.NewEncoder(file).Encode(runner.Options.LinterConfig)
+	return json.NewEncoder(file).Encode(cfg)
 }
 
-func hasGoCode(patchFile string) (bool, error) {
-	f, err := os.Open(patchFile)
-	if err != nil {
-		return false, err
-	}
-	defer f.Close()
-	m, err := linesChanged(f)
-	if err != nil {
-		return false, err
-	}
-
-	for k := range m {
-		if strings.HasSuffix(k, ".go") {
-			return true, nil
+func hasGoCode(patch *diff.Diff) bool {
+	for _, f := range patch.Files {
+		if strings.HasSuffix(f.NewName, ".go") || strings.HasSuffix(f.OldName, ".go") {
+			return true
 		}
 	}
-
-	return false, nil
+	return false
 }
 
-func filterIssues(patchFile string, issues []result.Issue) ([]result.Issue, error) {
-	f, err := os.Open(patchFile)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	m, err := linesChanged(f)
-	if err != nil {
-		return nil, err
-	}
-	var filteredIssues []result.Issue
+const typecheckLinter = "typecheck"
+
+// filterIssues returns the issues that have at least one line added in the patch,
+// the issues in changed files outside of the added lines (sameFile)
+// and the typecheck issues in unchanged files (otherFile), e.g. because the patch broke them.
+// Issues of other linters in unchanged files are dropped, they were not introduced by the patch.
+func filterIssues(patch *diff.Diff, issues []result.Issue) (filtered, sameFile, otherFile []result.Issue) {
 	for _, i := range issues {
-		positions, ok := m[i.FilePath()]
-		if !ok {
+		f := patch.File(i.FilePath())
+		if f == nil {
+			if i.FromLinter == typecheckLinter {
+				otherFile = append(otherFile, i)
+			}
 			continue
 		}
-		for _, pos := range positions {
-			if pos.lineNo == i.Line() {
-				i.HunkPos = pos.hunkPos
-				filteredIssues = append(filteredIssues, i)
-				break
-			}
+		if _, _, ok := commentLines(f, &i); !ok {
+			sameFile = append(sameFile, i)
+			continue
 		}
+		filtered = append(filtered, i)
 	}
-	return filteredIssues, nil
-}
-
-type pos struct {
-	lineNo  int // line number
-	hunkPos int // position relative to first @@ in file
+	return filtered, sameFile, otherFile
 }
 
-// readLine returns a single line (without the ending \n)
-// from the input buffered reader.
-// An error is returned iff there is an error with the
-// buffered reader.
-func readLine(r *bufio.Reader) (string, error) {
-	var (
-		isPrefix bool  = true
-		err      error = nil
-		line, ln []byte
-	)
-	for isPrefix && err == nil {
-		line, isPrefix, err = r.ReadLine()
-		ln = append(ln, line...)
+// hasTypecheckIssue returns true if one of the issues is a typecheck issue, i.e. the code does not compile.
+func hasTypecheckIssue(issues []result.Issue) bool {
+	for _, i := range issues {
+		if i.FromLinter == typecheckLinter {
+			return true
+		}
 	}
-	return string(ln), err
+	return false
 }
 
-// stolen from revgrep
-// horrible code please change
-//
-// linesChanges returns a map of file names to line numbers being changed.
-// If key is nil, the file has been recently added, else it contains a slice
-// of positions that have been added.
-func linesChanged(patch io.Reader) (map[string][]pos, error) {
-	var file string
-	var lineNo int    // current line number within chunk
-	var hunkPos int   // current line count since first @@ in file
-	var changes []pos // position of changes
-	// var hasHunk bool
-
-	fileChanges := make(map[string][]pos)
-
-	reader := bufio.NewReader(patch)
-	line, err := readLine(reader)
-	for err == nil {
-		// c.debugf(line)
-		lineNo++
-		hunkPos++
-		switch {
-		case strings.HasPrefix(line, "+++ ") && len(line) > 4:
-			if changes != nil {
-				// record the last state
-				fileChanges[file] = changes
-			}
-			// 6 removes "+++ b/"
-			file = line[6:]
-			// if !hasHunk {
-			hunkPos = -1
-			// hasHunk = true
-			// }
-
-			changes = []pos{}
-		case strings.HasPrefix(line, "@@ "):
-			//      @@ -1 +2,4 @@
-			// chdr ^^^^^^^^^^^^^
-			// ahdr       ^^^^
-			// cstart      ^
-			chdr := strings.Split(line, " ")
-			ahdr := strings.Split(chdr[2], ",")
-			// [1:] to remove leading plus
-			cstart, err := strconv.ParseUint(ahdr[0][1:], 10, 64)
-			if err != nil {
-				panic(err)
-			}
-			lineNo = int(cstart) - 1 // -1 as cstart is the next line number
-		case strings.HasPrefix(line, "-"):
-			lineNo--
-		case strings.HasPrefix(line, "+"):
-			changes = append(changes, pos{lineNo: lineNo, hunkPos: hunkPos})
-		}
-		line, err = readLine(reader)
-	}
-	if err == io.EOF {
-		err = nil
+// appendLinterName appends the linter name to the text of the issues.
+func appendLinterName(issues []result.Issue) {
+	for i := range issues {
+		issues[i].Text += fmt.Sprintf(" (from %s)", issues[i].FromLinter)
 	}
-	// record the last state
-	fileChanges[file] = changes
-	return fileChanges, err
 }
@@ -0,0 +1,137 @@
+package golangci_lint_runner
+
+import (
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/stretchr/testify/require"
+	"github.com/talon-one/golangci-lint-runner/internal/diff"
+)
+
+func TestFilterIssues(t *testing.T) {
+	patch, err := diff.Parse(strings.NewReader(testPatch))
+	require.NoError(t, err)
+
+	issue := func(linter, file string, line int) result.Issue {
+		return result.Issue{FromLinter: linter, Pos: token.Position{Filename: file, Line: line}}
+	}
+	added := issue("golint", "main.go", 3)
+	context := issue("golint", "main.go", 1)
+	outsideHunk := issue("errcheck", "main.go", 15)
+	otherTypecheck := issue("typecheck", "other.go", 5)
+	otherLinter := issue("golint", "other.go", 5)
+
+	filtered, sameFile, otherFile := filterIssues(patch, []result.Issue{added, context, outsideHunk, otherTypecheck, otherLinter})
+	require.Equal(t, []result.Issue{added}, filtered)
+	require.Equal(t, []result.Issue{context, outsideHunk}, sameFile)
+	require.Equal(t, []result.Issue{otherTypecheck}, otherFile)
+}
+
+func TestRunner_selectLinter(t *testing.T) {
+	linterDir := t.TempDir()
+	installed := filepath.Join(linterDir, "1.31.0", "golangci-lint")
+	require.NoError(t, os.MkdirAll(filepath.Dir(installed), 0700))
+	require.NoError(t, ioutil.WriteFile(installed, []byte("#!/bin/sh\n"), 0700))
+
+	tests := []struct {
+		name        string
+		linterDir   string
+		config      string
+		file        string
+		baseFile    string
+		base        bool
+		linterPath  string
+		unavailable string
+	}{
+		{
+			name:      "no version",
+			linterDir: linterDir,
+		},
+		{
+			name:       "config",
+			linterDir:  linterDir,
+			config:     "v1.31.0",
+			linterPath: installed,
+		},
+		{
+			name:       "version file",
+			linterDir:  linterDir,
+			file:       "1.31.0\n",
+			linterPath: installed,
+		},
+		{
+			name:        "config takes precedence",
+			linterDir:   linterDir,
+			config:      "1.29.0",
+			file:        "1.31.0",
+			unavailable: "1.29.0",
+		},
+		{
+			name:        "not installed",
+			linterDir:   linterDir,
+			file:        "1.32.0",
+			unavailable: "1.32.0",
+		},
+		{
+			name:        "no linter dir",
+			file:        "1.31.0",
+			unavailable: "1.31.0",
+		},
+		{
+			name:        "invalid version",
+			linterDir:   linterDir,
+			file:        "../1.31.0",
+			unavailable: "../1.31.0",
+		},
+		{
+			name:       "base version file",
+			linterDir:  linterDir,
+			file:       "1.32.0",
+			baseFile:   "1.31.0",
+			base:       true,
+			linterPath: installed,
+		},
+		{
+			name:      "no base version file",
+			linterDir: linterDir,
+			file:      "1.32.0",
+			base:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoDir := t.TempDir()
+			if tt.file != "" {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, LinterVersionFile), []byte(tt.file), 0600))
+			}
+			if tt.config != "" {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, ".golangci.yml"), []byte("golangci-lint-runner:\n  version: "+tt.config+"\n"), 0600))
+			}
+			runner := Runner{
+				Options: &Options{
+					Logger:       logger{},
+					LinterDir:    tt.linterDir,
+					LinterConfig: config.Config{Run: config.Run{Config: ".golangci.yml"}},
+				},
+			}
+			if tt.base {
+				files := map[string]string{}
+				if tt.baseFile != "" {
+					files["owner/repo/"+LinterVersionFile+"@base"] = tt.baseFile
+				}
+				runner.Options.ConfigSource = ConfigSourceBase
+				runner.provider = &fileProvider{files: files}
+				runner.meta.Base = BranchMeta{OwnerName: "owner", RepoName: "repo", SHA: "base"}
+			}
+			require.NoError(t, runner.readRepoConfig(repoDir))
+			require.Equal(t, tt.unavailable, runner.selectLinter(repoDir))
+			require.Equal(t, tt.linterPath, runner.linterPath)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package golangci_lint_runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golangci/golangci-lint/pkg/report"
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/talon-one/golangci-lint-runner/internal/diff"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// LocalResult are the issues Local found.
+type LocalResult struct {
+	// Issues in the changed lines, the severity is SeverityError, SeverityWarning or SeverityInfo
+	Issues []result.Issue `json:"issues"`
+	// SameFile are the issues in changed files outside of the changed lines
+	SameFile []result.Issue `json:"same_file"`
+	// OtherFiles are the typecheck issues in unchanged files
+	OtherFiles []result.Issue   `json:"other_files"`
+	Warnings   []report.Warning `json:"warnings"`
+}
+
+// HasErrors returns true if an issue in the changed lines is an error.
+func (r *LocalResult) HasErrors() bool {
+	for i := range r.Issues {
+		if r.Issues[i].Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Local lints the git checkout in dir like a pull request from HEAD into base (e.g. origin/main) without GitHub.
+// Only committed changes are part of the diff, but the files are linted as they are in the work tree.
+// Options.LinterConfig is merged with the config of the repository (of the work tree, or of base with ConfigSourceBase),
+// the GitHub related options are not used.
+func Local(options Options, dir, base string) (*LocalResult, error) {
+	if options.Logger == nil {
+		return nil, errors.New("Logger must be specified")
+	}
+	if options.CacheDir == "" {
+		return nil, errors.New("CacheDir must be specified")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = time.Minute * 10
+	}
+	// there is no provider to read the org config from
+	if options.OrgConfig != "" {
+		options.Logger.Warn("the org config %s is not applied in local mode", options.OrgConfig)
+		options.OrgConfig = ""
+	}
+	templates, err := parseTemplates(options.Templates)
+	if err != nil {
+		return nil, fmt.Errorf("invalid templates: %w", err)
+	}
+	runner := Runner{
+		Options:   &options,
+		templates: templates,
+	}
+
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open git repository %s: %w", dir, err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get work tree: %w", err)
+	}
+	repoDir := worktree.Filesystem.Root()
+	if status, err := worktree.Status(); err == nil && !status.IsClean() {
+		runner.Options.Logger.Warn("%s has uncommitted changes, they are linted but not part of the diff", repoDir)
+	}
+
+	if runner.configSource() == ConfigSourceBase {
+		if runner.baseCommit, err = localCommit(repo, base); err != nil {
+			return nil, err
+		}
+		runner.meta.Base.SHA = runner.baseCommit.Hash.String()
+	}
+	if err := runner.readRepoConfig(repoDir); err != nil {
+		return nil, err
+	}
+	runner.selectLinter(repoDir)
+
+	patch, err := localPatch(repo, base)
+	if err != nil {
+		return nil, err
+	}
+
+	var res LocalResult
+	if !hasGoCode(patch) {
+		runner.Options.Logger.Debug("no go code present")
+		return &res, nil
+	}
+
+	// the work directory is kept to reuse the downloaded modules
+	workDir := filepath.Join(runner.Options.CacheDir, "work")
+	if err := os.MkdirAll(workDir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create work directory: %w", err)
+	}
+
+	lint, err := runner.runLinter(runner.Options.CacheDir, workDir, repoDir)
+	if err != nil {
+		return nil, err
+	}
+	if lint.Report != nil {
+		res.Warnings = lint.Report.Warnings
+	}
+
+	res.Issues, res.SameFile, res.OtherFiles = filterIssues(patch, lint.Issues)
+	for i := range res.Issues {
+		res.Issues[i].Severity = issueSeverity(&res.Issues[i])
+	}
+	return &res, nil
+}
+
+// localCommit returns the commit of the revision, e.g. origin/main.
+func localCommit(repo *git.Repository, revision string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve %s: %w", revision, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s commit: %w", revision, err)
+	}
+	return commit, nil
+}
+
+// localPatch returns the diff between the merge base of HEAD and base, and HEAD (like the diff of a pull request).
+func localPatch(repo *git.Repository, base string) (*diff.Diff, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("unable to get HEAD commit: %w", err)
+	}
+
+	baseCommit, err := localCommit(repo, base)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeBases, err := baseCommit.MergeBase(headCommit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get merge base of %s and HEAD: %w", base, err)
+	}
+	if len(mergeBases) == 0 {
+		return nil, fmt.Errorf("%s and HEAD have no common history", base)
+	}
+
+	p, err := mergeBases[0].Patch(headCommit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create patch: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := p.Encode(&buf); err != nil {
+		return nil, fmt.Errorf("unable to encode patch: %w", err)
+	}
+	patch, err := diff.Parse(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse patch: %w", err)
+	}
+	return patch, nil
+}
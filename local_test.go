@@ -0,0 +1,104 @@
+package golangci_lint_runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/golangci/golangci-lint/pkg/printers"
+	"github.com/golangci/golangci-lint/pkg/report"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestLocalPatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golangci-lint-runner-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commit := func(files map[string]string) plumbing.Hash {
+		for name, content := range files {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+			_, err := worktree.Add(name)
+			require.NoError(t, err)
+		}
+		hash, err := worktree.Commit("commit", &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash
+	}
+
+	base := commit(map[string]string{
+		"main.go":   "package main\n\nfunc main() {\n}\n",
+		"README.md": "readme\n",
+	})
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/base", base)))
+	commit(map[string]string{
+		"main.go": "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+		"util.go": "package main\n",
+	})
+
+	patch, err := localPatch(repo, "base")
+	require.NoError(t, err)
+	require.Len(t, patch.Files, 2)
+
+	mainFile := patch.File("main.go")
+	require.NotNil(t, mainFile)
+	added := mainFile.AddedLines()
+	require.Len(t, added, 1)
+	require.Equal(t, 4, added[0].NewLine)
+
+	utilFile := patch.File("util.go")
+	require.NotNil(t, utilFile)
+	require.True(t, utilFile.IsNew)
+
+	_, err = localPatch(repo, "unknown")
+	require.Error(t, err)
+}
+
+func TestLocal_configSource(t *testing.T) {
+	tests := []struct {
+		configSource string
+		enable       []string
+	}{
+		{configSource: ConfigSourceHead, enable: []string{"errcheck"}},
+		{configSource: ConfigSourceBase, enable: []string{"misspell"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.configSource, func(t *testing.T) {
+			root := t.TempDir()
+			fixture := newFixtureRepo(t, root, "repo",
+				map[string]string{"main.go": e2eBase, ".golangci.yml": "linters:\n  enable: [misspell]\n"},
+				map[string]string{"main.go": e2eHead, ".golangci.yml": "linters:\n  enable: [errcheck]\n"},
+			)
+			installStubLinter(t, &printers.JSONResult{Report: &report.Data{}}, nil)
+
+			cacheDir := t.TempDir()
+			_, err := Local(Options{
+				Logger:       logger{},
+				CacheDir:     cacheDir,
+				LinterConfig: config.Config{Run: config.Run{Config: ".golangci.yml"}},
+				ConfigSource: tt.configSource,
+			}, filepath.Join(root, fixture.Name), "main")
+			require.NoError(t, err)
+
+			buf, err := ioutil.ReadFile(filepath.Join(cacheDir, "work", "golangci-lint.json"))
+			require.NoError(t, err)
+			var cfg config.Config
+			require.NoError(t, json.Unmarshal(buf, &cfg))
+			require.Equal(t, tt.enable, cfg.Linters.Enable)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package golangci_lint_runner
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Log formats of NewLogger.
+const (
+	LogFormatText   = "text"
+	LogFormatLogfmt = "logfmt"
+	LogFormatJSON   = "json"
+)
+
+// LogFormats returns the formats NewLogger supports.
+func LogFormats() []string {
+	return []string{LogFormatText, LogFormatLogfmt, LogFormatJSON}
+}
+
+// LogLevels returns the levels NewLogger supports.
+func LogLevels() []string {
+	return []string{"debug", "info", "warn", "error"}
+}
+
+// Fields are key value pairs that are added to every message of a logger.
+type Fields map[string]interface{}
+
+// FieldLogger is a Logger that can add fields to its messages.
+// The server and the runner add the fields of a job (delivery, installation, repo, pull request and head sha)
+// if the Logger of the Options implements it.
+type FieldLogger interface {
+	Logger
+	WithFields(fields Fields) Logger
+}
+
+// withFields returns a logger that adds fields to every message, loggers that are no FieldLogger are returned unchanged.
+func withFields(logger Logger, fields Fields) Logger {
+	if l, ok := logger.(FieldLogger); ok {
+		return l.WithFields(fields)
+	}
+	return logger
+}
+
+// StructuredLogger is a FieldLogger writing leveled text, logfmt or json lines.
+type StructuredLogger struct {
+	entry *logrus.Entry
+}
+
+// NewLogger creates a StructuredLogger that writes messages of level and above in format to w.
+func NewLogger(w io.Writer, format, level string) (*StructuredLogger, error) {
+	logger := logrus.New()
+	logger.SetOutput(w)
+
+	switch format {
+	case LogFormatText:
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	case LogFormatLogfmt:
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true, DisableColors: true})
+	case LogFormatJSON:
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+	logger.SetLevel(lvl)
+
+	return &StructuredLogger{entry: logrus.NewEntry(logger)}, nil
+}
+
+func (l *StructuredLogger) WithFields(fields Fields) Logger {
+	return &StructuredLogger{entry: l.entry.WithFields(logrus.Fields(fields))}
+}
+
+func (l *StructuredLogger) Debug(format string, a ...interface{}) {
+	l.entry.Debugf(format, a...)
+}
+
+func (l *StructuredLogger) Info(format string, a ...interface{}) {
+	l.entry.Infof(format, a...)
+}
+
+func (l *StructuredLogger) Warn(format string, a ...interface{}) {
+	l.entry.Warnf(format, a...)
+}
+
+func (l *StructuredLogger) Error(format string, a ...interface{}) {
+	l.entry.Errorf(format, a...)
+}
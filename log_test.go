@@ -0,0 +1,66 @@
+package golangci_lint_runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		level  string
+		lines  int
+		err    bool
+	}{
+		{name: "json", format: LogFormatJSON, level: "debug", lines: 2},
+		{name: "logfmt", format: LogFormatLogfmt, level: "info", lines: 1},
+		{name: "level", format: LogFormatText, level: "error", lines: 0},
+		{name: "unknown format", format: "xml", level: "info", err: true},
+		{name: "unknown level", format: LogFormatJSON, level: "verbose", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := NewLogger(&buf, tt.format, tt.level)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			logger.Debug("debug %d", 1)
+			logger.Info("info %d", 2)
+			require.Equal(t, tt.lines, strings.Count(buf.String(), "\n"))
+		})
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := NewLogger(&buf, LogFormatJSON, "info")
+		require.NoError(t, err)
+		withFields(logger, Fields{"repo": "owner/repo", "pull_request": 1}).Warn("failed %s", "job")
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		require.Equal(t, "failed job", entry["msg"])
+		require.Equal(t, "warning", entry["level"])
+		require.Equal(t, "owner/repo", entry["repo"])
+		require.Equal(t, float64(1), entry["pull_request"])
+	})
+
+	t.Run("plain logger", func(t *testing.T) {
+		require.Equal(t, logger{}, withFields(logger{}, Fields{"repo": "owner/repo"}))
+	})
+
+	t.Run("logfmt", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := NewLogger(&buf, LogFormatLogfmt, "info")
+		require.NoError(t, err)
+		logger.WithFields(Fields{"sha": "abc"}).Info("done")
+		require.Contains(t, buf.String(), `level=info msg=done sha=abc`)
+	})
+}
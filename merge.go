@@ -0,0 +1,219 @@
+package golangci_lint_runner
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Merge strategies for the settings of a config file, see Options.MergeStrategies.
+const (
+	// MergeReplace replaces the setting (the default), maps are merged key by key unless the strategy is set for them
+	MergeReplace = "replace"
+	// MergeAppend appends the items of a list that are not yet present
+	MergeAppend = "append"
+	// MergeRemove removes the items of a list
+	MergeRemove = "remove"
+)
+
+// mergeKey is the key of the merge strategies in a config file.
+const mergeKey = "golangci-lint-runner.merge"
+
+// MergeStrategies returns the supported merge strategies.
+func MergeStrategies() []string {
+	return []string{MergeReplace, MergeAppend, MergeRemove}
+}
+
+// validateMergeStrategies checks that strategies maps setting paths to known strategies.
+func validateMergeStrategies(strategies map[string]string) error {
+	for path, strategy := range strategies {
+		switch strategy {
+		case MergeReplace, MergeAppend, MergeRemove:
+		default:
+			return fmt.Errorf("unknown merge strategy %q for %s", strategy, path)
+		}
+	}
+	return nil
+}
+
+// flattenSettings returns the leaves of the nested settings by their dot separated path.
+func flattenSettings(prefix string, settings map[string]interface{}, flat map[string]string) {
+	for key, value := range settings {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenSettings(prefix+key+".", nested, flat)
+			continue
+		}
+		flat[prefix+key] = fmt.Sprint(value)
+	}
+}
+
+// mergeStrategies returns the defaults overridden by the strategies of the config file settings.
+func mergeStrategies(defaults map[string]string, settings map[string]interface{}) (map[string]string, error) {
+	strategies := make(map[string]string, len(defaults))
+	for path, strategy := range defaults {
+		strategies[strings.ToLower(path)] = strategy
+	}
+	var section interface{} = settings
+	for _, key := range strings.Split(mergeKey, ".") {
+		m, ok := section.(map[string]interface{})
+		if !ok {
+			section = nil
+			break
+		}
+		section = m[key]
+	}
+	switch s := section.(type) {
+	case nil:
+	case map[string]interface{}:
+		flattenSettings("", s, strategies)
+	default:
+		return nil, fmt.Errorf("%s must be a map", mergeKey)
+	}
+	if err := validateMergeStrategies(strategies); err != nil {
+		return nil, err
+	}
+	return strategies, nil
+}
+
+// mergeConfig merges the fields of src that are present in settings (the nested settings of the config file src was
+// read from) into dst, dst and src must be pointers to the same struct type.
+// Settings that have no field (like the golangci-lint-runner section) are skipped.
+// Maps and pointers of dst are copied before they are changed, dst may share them with other configs
+// (e.g. a shallow copy of the default config).
+func mergeConfig(dst, src interface{}, settings map[string]interface{}, strategies map[string]string) error {
+	return mergeStruct(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem(), settings, strategies, "")
+}
+
+func mergeStruct(dst, src reflect.Value, settings map[string]interface{}, strategies map[string]string, prefix string) error {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		index, ok := fieldIndex(dst.Type(), key)
+		if !ok {
+			continue
+		}
+		path := prefix + key
+		dstField := dst.FieldByIndex(index)
+		srcField := src.FieldByIndex(index)
+		strategy := strategies[path]
+
+		if nested, ok := settings[key].(map[string]interface{}); ok && strategy == "" {
+			if dstField.Kind() == reflect.Ptr && dstField.Type().Elem().Kind() == reflect.Struct {
+				if srcField.IsNil() {
+					continue
+				}
+				copied := reflect.New(dstField.Type().Elem())
+				if !dstField.IsNil() {
+					copied.Elem().Set(dstField.Elem())
+				}
+				dstField.Set(copied)
+				dstField, srcField = dstField.Elem(), srcField.Elem()
+			}
+			if dstField.Kind() == reflect.Struct {
+				if err := mergeStruct(dstField, srcField, nested, strategies, path+"."); err != nil {
+					return err
+				}
+				continue
+			}
+			if dstField.Kind() == reflect.Map {
+				mergeMap(dstField, srcField)
+				continue
+			}
+		}
+
+		switch strategy {
+		case "", MergeReplace:
+			dstField.Set(srcField)
+		case MergeAppend, MergeRemove:
+			if dstField.Kind() != reflect.Slice {
+				return fmt.Errorf("%s is no list, it can not be merged with %s", path, strategy)
+			}
+			if strategy == MergeAppend {
+				dstField.Set(appendMissing(dstField, srcField))
+			} else {
+				dstField.Set(removeItems(dstField, srcField))
+			}
+		default:
+			return fmt.Errorf("unknown merge strategy %q for %s", strategy, path)
+		}
+	}
+	return nil
+}
+
+// fieldIndex returns the index of the field for key, which is matched case insensitive against the mapstructure
+// tag or the field name, fields of squashed structs are included.
+func fieldIndex(t reflect.Type, key string) ([]int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		tag := strings.Split(field.Tag.Get("mapstructure"), ",")
+		if tag[0] != "" {
+			name = tag[0]
+		}
+		if len(tag) > 1 && tag[1] == "squash" && field.Type.Kind() == reflect.Struct {
+			if index, ok := fieldIndex(field.Type, key); ok {
+				return append([]int{i}, index...), true
+			}
+			continue
+		}
+		if strings.EqualFold(name, key) {
+			return []int{i}, true
+		}
+	}
+	return nil, false
+}
+
+// mergeMap sets dst to a copy of dst with the entries of src.
+func mergeMap(dst, src reflect.Value) {
+	if src.IsNil() {
+		return
+	}
+	merged := reflect.MakeMapWithSize(dst.Type(), dst.Len()+src.Len())
+	for _, m := range []reflect.Value{dst, src} {
+		iter := m.MapRange()
+		for iter.Next() {
+			merged.SetMapIndex(iter.Key(), iter.Value())
+		}
+	}
+	dst.Set(merged)
+}
+
+func containsItem(list, item reflect.Value) bool {
+	for i := 0; i < list.Len(); i++ {
+		if reflect.DeepEqual(list.Index(i).Interface(), item.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendMissing returns list with the items that are not yet present.
+func appendMissing(list, items reflect.Value) reflect.Value {
+	result := reflect.MakeSlice(list.Type(), 0, list.Len()+items.Len())
+	result = reflect.AppendSlice(result, list)
+	for i := 0; i < items.Len(); i++ {
+		if !containsItem(result, items.Index(i)) {
+			result = reflect.Append(result, items.Index(i))
+		}
+	}
+	return result
+}
+
+// removeItems returns list without items.
+func removeItems(list, items reflect.Value) reflect.Value {
+	result := reflect.MakeSlice(list.Type(), 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		if !containsItem(items, list.Index(i)) {
+			result = reflect.Append(result, list.Index(i))
+		}
+	}
+	return result
+}
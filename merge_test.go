@@ -0,0 +1,169 @@
+package golangci_lint_runner
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_mergeConfig(t *testing.T) {
+	base := func() config.Config {
+		return config.Config{
+			Run: config.Run{Config: ".golangci.yml", SkipDirs: []string{"vendor", "gen"}},
+			Linters: config.Linters{
+				Enable:     []string{"govet", "errcheck"},
+				DisableAll: true,
+			},
+			LintersSettings: config.LintersSettings{
+				Lll:      config.LllSettings{LineLength: 120, TabWidth: 1},
+				Gocritic: config.GocriticSettings{SettingsPerCheck: map[string]config.GocriticCheckSettings{"hugeparam": {"sizethreshold": 80}}},
+			},
+			Issues: config.Issues{ExcludeRules: []config.ExcludeRule{{BaseRule: config.BaseRule{Linters: []string{"lll"}}}}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		strategies map[string]string
+		repoConfig string
+		expect     func(cfg *config.Config)
+		err        string
+	}{
+		{
+			name:       "lists are replaced by default",
+			repoConfig: "linters:\n  enable: [misspell]\n",
+			expect: func(cfg *config.Config) {
+				cfg.Linters.Enable = []string{"misspell"}
+			},
+		},
+		{
+			name:       "append",
+			repoConfig: "linters:\n  enable: [misspell, govet]\ngolangci-lint-runner:\n  merge:\n    linters.enable: append\n",
+			expect: func(cfg *config.Config) {
+				cfg.Linters.Enable = []string{"govet", "errcheck", "misspell"}
+			},
+		},
+		{
+			name:       "remove",
+			repoConfig: "run:\n  skip-dirs: [gen]\ngolangci-lint-runner:\n  merge:\n    run:\n      skip-dirs: remove\n",
+			expect: func(cfg *config.Config) {
+				cfg.Run.SkipDirs = []string{"vendor"}
+			},
+		},
+		{
+			name:       "server strategy",
+			strategies: map[string]string{"linters.enable": MergeAppend},
+			repoConfig: "linters:\n  enable: [misspell]\n",
+			expect: func(cfg *config.Config) {
+				cfg.Linters.Enable = []string{"govet", "errcheck", "misspell"}
+			},
+		},
+		{
+			name:       "repository overrides server strategy",
+			strategies: map[string]string{"linters.enable": MergeAppend},
+			repoConfig: "linters:\n  enable: [misspell]\ngolangci-lint-runner:\n  merge:\n    linters.enable: replace\n",
+			expect: func(cfg *config.Config) {
+				cfg.Linters.Enable = []string{"misspell"}
+			},
+		},
+		{
+			name:       "booleans can be unset",
+			repoConfig: "linters:\n  disable-all: false\n",
+			expect: func(cfg *config.Config) {
+				cfg.Linters.DisableAll = false
+			},
+		},
+		{
+			name:       "settings that are not present are kept",
+			repoConfig: "linters-settings:\n  lll:\n    line-length: 80\n",
+			expect: func(cfg *config.Config) {
+				cfg.LintersSettings.Lll.LineLength = 80
+			},
+		},
+		{
+			name:       "maps are merged",
+			repoConfig: "linters-settings:\n  gocritic:\n    settings:\n      rangevalcopy:\n        sizethreshold: 32\n",
+			expect: func(cfg *config.Config) {
+				cfg.LintersSettings.Gocritic.SettingsPerCheck["rangevalcopy"] = config.GocriticCheckSettings{"sizethreshold": 32}
+			},
+		},
+		{
+			name:       "maps can be replaced",
+			repoConfig: "linters-settings:\n  gocritic:\n    settings:\n      rangevalcopy:\n        sizethreshold: 32\ngolangci-lint-runner:\n  merge:\n    linters-settings.gocritic.settings: replace\n",
+			expect: func(cfg *config.Config) {
+				cfg.LintersSettings.Gocritic.SettingsPerCheck = map[string]config.GocriticCheckSettings{"rangevalcopy": {"sizethreshold": 32}}
+			},
+		},
+		{
+			name:       "squashed fields",
+			repoConfig: "issues:\n  exclude-rules:\n    - linters: [dupl]\ngolangci-lint-runner:\n  merge:\n    issues.exclude-rules: append\n",
+			expect: func(cfg *config.Config) {
+				cfg.Issues.ExcludeRules = append(cfg.Issues.ExcludeRules, config.ExcludeRule{BaseRule: config.BaseRule{Linters: []string{"dupl"}}})
+			},
+		},
+		{
+			name:       "append to a value",
+			repoConfig: "linters-settings:\n  lll:\n    line-length: 80\ngolangci-lint-runner:\n  merge:\n    linters-settings.lll.line-length: append\n",
+			err:        "invalid config .golangci.yml: linters-settings.lll.line-length is no list, it can not be merged with append",
+		},
+		{
+			name:       "unknown strategy",
+			repoConfig: "golangci-lint-runner:\n  merge:\n    linters.enable: prepend\n",
+			err:        `invalid config .golangci.yml: invalid merge strategies: unknown merge strategy "prepend" for linters.enable`,
+		},
+		{
+			name:       "invalid yaml",
+			repoConfig: "linters: [",
+			err:        "invalid config .golangci.yml: line 1: did not find expected node content",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".golangci.yml"), []byte(tt.repoConfig), 0600))
+			// the runner gets a shallow copy of the default config, like the jobs of the server
+			defaults := base()
+			r := &Runner{
+				Options: &Options{
+					Logger:          logger{},
+					LinterConfig:    defaults,
+					MergeStrategies: tt.strategies,
+				},
+			}
+			err := r.readRepoConfig(dir)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			expect := base()
+			tt.expect(&expect)
+			require.Equal(t, expect, r.Options.LinterConfig)
+			require.Equal(t, base(), defaults)
+		})
+	}
+}
+
+func TestMergeConfig_copies(t *testing.T) {
+	type settings struct {
+		A, B int
+	}
+	type cfg struct {
+		Map map[string]int
+		Ptr *settings
+	}
+	dst := cfg{Map: map[string]int{"a": 1}, Ptr: &settings{A: 1}}
+	shared := dst
+	src := cfg{Map: map[string]int{"b": 2}, Ptr: &settings{B: 2}}
+
+	err := mergeConfig(&dst, &src, map[string]interface{}{
+		"map": map[string]interface{}{"b": 2},
+		"ptr": map[string]interface{}{"b": 2},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, cfg{Map: map[string]int{"a": 1, "b": 2}, Ptr: &settings{A: 1, B: 2}}, dst)
+	require.Equal(t, cfg{Map: map[string]int{"a": 1}, Ptr: &settings{A: 1}}, shared)
+}
@@ -0,0 +1,100 @@
+package golangci_lint_runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// OrgConfigOwner is replaced with the owner of the pull request's base repository in Options.OrgConfig.
+const OrgConfigOwner = "{owner}"
+
+// OrgConfigCache caches shared configs by the commit they were read from, it is safe to share it between runners.
+type OrgConfigCache struct {
+	mu sync.Mutex
+	// entries maps a location to the config of the last seen commit
+	entries map[string]orgConfigEntry
+}
+
+type orgConfigEntry struct {
+	commit string
+	// content is nil if the file does not exist in the commit
+	content []byte
+}
+
+func NewOrgConfigCache() *OrgConfigCache {
+	return &OrgConfigCache{entries: make(map[string]orgConfigEntry)}
+}
+
+func (c *OrgConfigCache) get(location, commit string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[location]
+	if !ok || entry.commit != commit {
+		return nil, false
+	}
+	return entry.content, true
+}
+
+func (c *OrgConfigCache) set(location, commit string, content []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[location] = orgConfigEntry{commit: commit, content: content}
+}
+
+// parseOrgConfigLocation splits location (owner/repo/path) after replacing OrgConfigOwner with owner.
+func parseOrgConfigLocation(location, owner string) (repoOwner, repoName, path string, err error) {
+	parts := strings.SplitN(strings.ReplaceAll(location, OrgConfigOwner, owner), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid org config location %q, expected owner/repo/path", location)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// readOrgConfig applies the shared config of Options.OrgConfig, if it exists.
+// The file is read from the head of the default branch and cached by its commit.
+// An invalid org config is not returned as *ConfigError, it is not the fault of the pull request and is not reported on it.
+func (r *Runner) readOrgConfig() error {
+	if r.Options.OrgConfig == "" {
+		return nil
+	}
+	owner, name, path, err := parseOrgConfigLocation(r.Options.OrgConfig, r.meta.Base.OwnerName)
+	if err != nil {
+		return err
+	}
+	location := owner + "/" + name + "/" + path
+
+	commit, err := r.provider.DefaultBranchCommit(r.Options.Context, owner, name)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrNotSupported) {
+			r.Options.Logger.Debug("no org config %s: %s", location, err)
+			return nil
+		}
+		return fmt.Errorf("unable to get commit of org config %s: %w", location, err)
+	}
+
+	content, ok := r.Options.OrgConfigCache.get(location, commit)
+	if !ok {
+		r.Options.Logger.Debug("downloading org config %s at %s", location, commit)
+		content, err = r.provider.File(r.Options.Context, owner, name, path, commit)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("unable to download org config %s: %w", location, err)
+			}
+			content = nil
+		}
+		r.Options.OrgConfigCache.set(location, commit, content)
+	}
+	if content == nil {
+		r.Options.Logger.Debug("no org config %s", location)
+		return nil
+	}
+
+	r.Options.Logger.Debug("applying org config %s at %s", location, commit)
+	if err := r.applyConfig(bytes.NewReader(content), location, false); err != nil {
+		return fmt.Errorf("unable to apply org config at %s: %s", commit, err)
+	}
+	return nil
+}
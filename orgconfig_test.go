@@ -0,0 +1,200 @@
+package golangci_lint_runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+// fileProvider serves the files of the default branches, the other methods of Provider are not implemented.
+type fileProvider struct {
+	Provider
+	// commits maps owner/name to the commit of the default branch
+	commits map[string]string
+	// files maps owner/name/path@commit to the content
+	files     map[string]string
+	downloads int
+}
+
+func (p *fileProvider) DefaultBranchCommit(ctx context.Context, owner, name string) (string, error) {
+	commit, ok := p.commits[owner+"/"+name]
+	if !ok {
+		return "", fmt.Errorf("repository %s/%s: %w", owner, name, ErrNotFound)
+	}
+	return commit, nil
+}
+
+func (p *fileProvider) File(ctx context.Context, owner, name, path, ref string) ([]byte, error) {
+	p.downloads++
+	content, ok := p.files[owner+"/"+name+"/"+path+"@"+ref]
+	if !ok {
+		return nil, fmt.Errorf("file %s: %w", path, ErrNotFound)
+	}
+	return []byte(content), nil
+}
+
+func TestParseOrgConfigLocation(t *testing.T) {
+	owner, name, path, err := parseOrgConfigLocation("{owner}/.github/configs/golangci.yml", "org")
+	require.NoError(t, err)
+	require.Equal(t, []string{"org", ".github", "configs/golangci.yml"}, []string{owner, name, path})
+
+	_, _, _, err = parseOrgConfigLocation("{owner}/golangci.yml", "org")
+	require.Error(t, err)
+}
+
+func TestRunner_readOrgConfig(t *testing.T) {
+	const orgConfig = "linters:\n  enable: [errcheck]\nlinters-settings:\n  lll:\n    line-length: 100\n"
+	tests := []struct {
+		name      string
+		location  string
+		commits   map[string]string
+		files     map[string]string
+		repo      string
+		runs      int
+		enable    []string
+		length    int
+		downloads int
+	}{
+		{
+			name:    "disabled",
+			commits: map[string]string{"org/.github": "a"},
+			files:   map[string]string{"org/.github/golangci.yml@a": orgConfig},
+			runs:    1,
+			enable:  []string{"govet"},
+			length:  120,
+		},
+		{
+			name:      "applied",
+			location:  "{owner}/.github/golangci.yml",
+			commits:   map[string]string{"org/.github": "a"},
+			files:     map[string]string{"org/.github/golangci.yml@a": orgConfig},
+			runs:      1,
+			enable:    []string{"errcheck"},
+			length:    100,
+			downloads: 1,
+		},
+		{
+			name:      "repo config overrides org config",
+			location:  "{owner}/.github/golangci.yml",
+			commits:   map[string]string{"org/.github": "a"},
+			files:     map[string]string{"org/.github/golangci.yml@a": orgConfig},
+			repo:      "linters-settings:\n  lll:\n    line-length: 80\n",
+			runs:      1,
+			enable:    []string{"errcheck"},
+			length:    80,
+			downloads: 1,
+		},
+		{
+			name:      "cached by commit",
+			location:  "{owner}/.github/golangci.yml",
+			commits:   map[string]string{"org/.github": "a"},
+			files:     map[string]string{"org/.github/golangci.yml@a": orgConfig},
+			runs:      3,
+			enable:    []string{"errcheck"},
+			length:    100,
+			downloads: 1,
+		},
+		{
+			name:      "missing file is cached",
+			location:  "{owner}/.github/golangci.yml",
+			commits:   map[string]string{"org/.github": "a"},
+			runs:      2,
+			enable:    []string{"govet"},
+			length:    120,
+			downloads: 1,
+		},
+		{
+			name:     "missing repository",
+			location: "{owner}/.github/golangci.yml",
+			runs:     1,
+			enable:   []string{"govet"},
+			length:   120,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fileProvider{commits: tt.commits, files: tt.files}
+			cache := NewOrgConfigCache()
+			for i := 0; i < tt.runs; i++ {
+				repoDir := t.TempDir()
+				if tt.repo != "" {
+					require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, ".golangci.yml"), []byte(tt.repo), 0600))
+				}
+				runner := Runner{
+					meta:     MetaData{Base: BranchMeta{OwnerName: "org", RepoName: "repo"}},
+					provider: provider,
+					Options: &Options{
+						Context:        context.Background(),
+						Logger:         logger{},
+						OrgConfig:      tt.location,
+						OrgConfigCache: cache,
+						LinterConfig: config.Config{
+							Run:             config.Run{Config: ".golangci.yml"},
+							Linters:         config.Linters{Enable: []string{"govet"}},
+							LintersSettings: config.LintersSettings{Lll: config.LllSettings{LineLength: 120}},
+						},
+					},
+				}
+				require.NoError(t, runner.readRepoConfig(repoDir))
+				require.Equal(t, tt.enable, runner.Options.LinterConfig.Linters.Enable)
+				require.Equal(t, tt.length, runner.Options.LinterConfig.LintersSettings.Lll.LineLength)
+			}
+			require.Equal(t, tt.downloads, provider.downloads)
+		})
+	}
+
+	t.Run("new commit", func(t *testing.T) {
+		provider := &fileProvider{
+			commits: map[string]string{"org/.github": "a"},
+			files: map[string]string{
+				"org/.github/golangci.yml@a": orgConfig,
+				"org/.github/golangci.yml@b": "linters:\n  enable: [misspell]\n",
+			},
+		}
+		cache := NewOrgConfigCache()
+		run := func() []string {
+			runner := Runner{
+				meta:     MetaData{Base: BranchMeta{OwnerName: "org"}},
+				provider: provider,
+				Options: &Options{
+					Context:        context.Background(),
+					Logger:         logger{},
+					OrgConfig:      "{owner}/.github/golangci.yml",
+					OrgConfigCache: cache,
+				},
+			}
+			require.NoError(t, runner.readOrgConfig())
+			return runner.Options.LinterConfig.Linters.Enable
+		}
+		require.Equal(t, []string{"errcheck"}, run())
+		provider.commits["org/.github"] = "b"
+		require.Equal(t, []string{"misspell"}, run())
+		require.Equal(t, 2, provider.downloads)
+	})
+
+	t.Run("invalid config is not a config error of the pull request", func(t *testing.T) {
+		runner := Runner{
+			meta: MetaData{Base: BranchMeta{OwnerName: "org"}},
+			provider: &fileProvider{
+				commits: map[string]string{"org/.github": "a"},
+				files:   map[string]string{"org/.github/golangci.yml@a": "run:\n  timeout: soon\n"},
+			},
+			Options: &Options{
+				Context:        context.Background(),
+				Logger:         logger{},
+				OrgConfig:      "{owner}/.github/golangci.yml",
+				OrgConfigCache: NewOrgConfigCache(),
+			},
+		}
+		err := runner.readOrgConfig()
+		require.EqualError(t, err, "unable to apply org config at a: invalid config org/.github/golangci.yml: line 2: run.timeout has an invalid value \"soon\"")
+		var configErr *ConfigError
+		require.False(t, errors.As(err, &configErr))
+	})
+}
@@ -0,0 +1,98 @@
+package golangci_lint_runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// What to do with comments of previous runs whose issues are no longer reported.
+const (
+	OutdatedCommentsKeep     = "keep"
+	OutdatedCommentsResolve  = "resolve"
+	OutdatedCommentsMinimize = "minimize"
+	OutdatedCommentsReply    = "reply"
+)
+
+const outdatedReplyMarker = "<!-- golangci-lint-runner:outdated -->"
+
+// isReported returns true if one of the comments matches the first comment of the thread (by fingerprint if present).
+func isReported(comments []*ReviewComment, thread *Thread) bool {
+	fingerprint, _ := parseMarker(thread.Body)
+	for _, comment := range comments {
+		if fingerprint != "" {
+			if comment.fingerprint == fingerprint {
+				return true
+			}
+			continue
+		}
+		if comment.GetPath() == thread.Path && stripMarker(comment.GetBody()) == stripMarker(thread.Body) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleOutdatedComments resolves, minimizes or replies to our comments from previous runs
+// that are not part of the current comments anymore.
+func (runner *Runner) handleOutdatedComments(comments []*ReviewComment) error {
+	action := runner.Options.OutdatedComments
+	if action == "" || action == OutdatedCommentsKeep {
+		return nil
+	}
+
+	threads, err := runner.provider.Threads(runner.Options.Context)
+	if errors.Is(err, ErrNotSupported) {
+		runner.Options.Logger.Debug("not handling outdated comments: %s", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to list review threads: %w", err)
+	}
+
+	for _, thread := range threads {
+		if thread.Resolved {
+			continue
+		}
+		if _, ok := parseMarker(thread.Body); !ok {
+			continue
+		}
+		if isReported(comments, thread) {
+			continue
+		}
+
+		if runner.Options.DryRun {
+			runner.Options.Logger.Info("not handling outdated comment %s (%s) because of dry run", thread.ID, action)
+			continue
+		}
+
+		switch action {
+		case OutdatedCommentsResolve:
+			runner.Options.Logger.Debug("resolving thread of outdated comment %s", thread.ID)
+			err = runner.provider.ResolveThread(runner.Options.Context, thread)
+		case OutdatedCommentsMinimize:
+			if thread.Minimized {
+				continue
+			}
+			runner.Options.Logger.Debug("minimizing outdated comment %s", thread.ID)
+			err = runner.provider.MinimizeThread(runner.Options.Context, thread)
+		case OutdatedCommentsReply:
+			if strings.Contains(thread.LastBody, outdatedReplyMarker) {
+				continue
+			}
+			runner.Options.Logger.Debug("replying to outdated comment %s", thread.ID)
+			var text string
+			text, err = execute(runner.templates.outdatedReply, &OutdatedData{PullRequest: runner.meta, Path: thread.Path, Body: stripMarker(thread.Body)})
+			if err != nil {
+				return err
+			}
+			err = runner.provider.ReplyToThread(runner.Options.Context, thread, text+"\n\n"+outdatedReplyMarker)
+		default:
+			return fmt.Errorf("unknown outdated comments action %q", action)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to %s outdated comment %s: %w", action, thread.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package golangci_lint_runner
+
+import (
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsReported(t *testing.T) {
+	comments := []*ReviewComment{
+		{Path: github.String("main.go"), Body: github.String("errcheck\n\n" + fingerprintMarker("0123abcd")), fingerprint: "0123abcd"},
+		{Path: github.String("util.go"), Body: github.String("unused\n\n" + fingerprintMarker("4567ef01")), fingerprint: "4567ef01"},
+	}
+
+	tests := []struct {
+		name   string
+		thread *Thread
+		want   bool
+	}{
+		{
+			name:   "same fingerprint",
+			thread: &Thread{Path: "main.go", Body: "errcheck\n\n" + fingerprintMarker("0123abcd")},
+			want:   true,
+		},
+		{
+			name:   "same fingerprint with other text",
+			thread: &Thread{Path: "main.go", Body: "errcheck (old template)\n\n" + fingerprintMarker("0123abcd")},
+			want:   true,
+		},
+		{
+			name:   "other fingerprint with same text",
+			thread: &Thread{Path: "main.go", Body: "errcheck\n\n" + fingerprintMarker("89abcdef")},
+		},
+		{
+			name:   "legacy marker with same text",
+			thread: &Thread{Path: "util.go", Body: "unused\n\n<!-- golangci-lint-runner -->"},
+			want:   true,
+		},
+		{
+			name:   "legacy marker with other path",
+			thread: &Thread{Path: "main.go", Body: "unused\n\n<!-- golangci-lint-runner -->"},
+		},
+		{
+			name:   "legacy marker with other text",
+			thread: &Thread{Path: "util.go", Body: "deadcode\n\n<!-- golangci-lint-runner -->"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isReported(comments, tt.thread))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package golangci_lint_runner
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigPolicy is used if Options.ConfigPolicy is not set, it allows the settings that change what is
+// reported, but not what golangci-lint loads, skips or writes, and not the default severity of all issues.
+var DefaultConfigPolicy = []string{
+	"linters",
+	"linters-settings",
+	"!linters-settings.custom",
+	"issues",
+	"severity",
+	"!severity.default-severity",
+	"run.tests",
+	"run.build-tags",
+	"output.print-linter-name",
+	"golangci-lint-runner",
+}
+
+// policyRule returns whether the most specific rule of policy allows path and whether policy has rules for
+// settings below path.
+func policyRule(policy []string, path string) (allowed, nested bool) {
+	match := -1
+	for _, rule := range policy {
+		deny := strings.HasPrefix(rule, "!")
+		setting := strings.ToLower(strings.TrimPrefix(rule, "!"))
+		if setting == "*" {
+			setting = ""
+		}
+		switch {
+		case setting == "" || setting == path || strings.HasPrefix(path, setting+"."):
+			// a deny rule wins over an allow rule for the same setting
+			if len(setting) > match || len(setting) == match && deny {
+				match = len(setting)
+				allowed = !deny
+			}
+		case strings.HasPrefix(setting, path+"."):
+			nested = true
+		}
+	}
+	return allowed, nested
+}
+
+// forbiddenSetting is a setting of a config file that the policy does not allow.
+type forbiddenSetting struct {
+	path string
+	line int
+}
+
+// forbiddenSettings returns the settings of the yaml mapping node that policy does not allow.
+func forbiddenSettings(policy []string, node *yaml.Node, prefix string) []forbiddenSetting {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var forbidden []forbiddenSetting
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind == yaml.AliasNode {
+			value = value.Alias
+		}
+		path := joinPath(prefix, strings.ToLower(key.Value))
+		allowed, nested := policyRule(policy, path)
+		if nested && value.Kind == yaml.MappingNode {
+			forbidden = append(forbidden, forbiddenSettings(policy, value, path)...)
+			continue
+		}
+		if !allowed {
+			forbidden = append(forbidden, forbiddenSetting{path: path, line: key.Line})
+		}
+	}
+	return forbidden
+}
+
+// deleteSetting deletes the dot separated path from the nested settings.
+func deleteSetting(settings map[string]interface{}, path string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := settings[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		settings = nested
+	}
+	delete(settings, keys[len(keys)-1])
+}
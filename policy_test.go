@@ -0,0 +1,90 @@
+package golangci_lint_runner
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyRule(t *testing.T) {
+	policy := []string{"linters", "linters-settings", "!linters-settings.custom", "run.tests", "!issues", "issues"}
+	tests := []struct {
+		path    string
+		allowed bool
+		nested  bool
+	}{
+		{path: "linters", allowed: true},
+		{path: "linters.enable", allowed: true},
+		{path: "linters-settings", allowed: true, nested: true},
+		{path: "linters-settings.lll", allowed: true},
+		{path: "linters-settings.custom", allowed: false},
+		{path: "linters-settings.custom.example.path", allowed: false},
+		{path: "run", allowed: false, nested: true},
+		{path: "run.tests", allowed: true},
+		{path: "run.skip-dirs", allowed: false},
+		{path: "linters-settingsx", allowed: false},
+		{path: "issues", allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			allowed, nested := policyRule(policy, tt.path)
+			require.Equal(t, tt.allowed, allowed)
+			require.Equal(t, tt.nested, nested)
+		})
+	}
+
+	allowed, _ := policyRule([]string{"*", "!run"}, "output.format")
+	require.True(t, allowed)
+	allowed, _ = policyRule([]string{"*", "!run"}, "run.skip-dirs")
+	require.False(t, allowed)
+}
+
+func TestRunner_applyPolicy(t *testing.T) {
+	const repoConfig = "run:\n  tests: false\n  skip-dirs: [.]\n  skip-files: [.*]\nlinters:\n  enable: [misspell]\nlinters-settings:\n  lll:\n    line-length: 80\n  custom:\n    example:\n      path: /tmp/example.so\n" +
+		"severity:\n  default-severity: info\n  rules:\n    - linters: [lll]\n      severity: warning\n"
+	newRunner := func(reject bool) *Runner {
+		return &Runner{
+			Options: &Options{
+				Logger: logger{},
+				LinterConfig: config.Config{
+					Run:     config.Run{Config: ".golangci.yml", AnalyzeTests: true, SkipDirs: []string{"vendor"}},
+					Linters: config.Linters{Enable: []string{"govet"}},
+				},
+				ConfigPolicy:            DefaultConfigPolicy,
+				RejectForbiddenSettings: reject,
+			},
+		}
+	}
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".golangci.yml"), []byte(repoConfig), 0600))
+
+	t.Run("ignore", func(t *testing.T) {
+		runner := newRunner(false)
+		require.NoError(t, runner.readRepoConfig(dir))
+		cfg := runner.Options.LinterConfig
+		require.False(t, cfg.Run.AnalyzeTests)
+		require.Equal(t, []string{"vendor"}, cfg.Run.SkipDirs)
+		require.Equal(t, []string{"misspell"}, cfg.Linters.Enable)
+		require.Equal(t, 80, cfg.LintersSettings.Lll.LineLength)
+		require.Empty(t, cfg.LintersSettings.Custom)
+		require.Empty(t, cfg.Run.SkipFiles)
+		require.Empty(t, cfg.Severity.Default)
+		require.Equal(t, []config.SeverityRule{{Severity: "warning", BaseRule: config.BaseRule{Linters: []string{"lll"}}}}, cfg.Severity.Rules)
+		require.Equal(t, []string{"run.skip-dirs", "run.skip-files", "linters-settings.custom", "severity.default-severity"}, runner.ignoredSettings)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		runner := newRunner(true)
+		err := runner.readRepoConfig(dir)
+		require.Equal(t, &ConfigError{File: ".golangci.yml", Problems: []ConfigProblem{
+			{Line: 3, Message: "run.skip-dirs is not allowed"},
+			{Line: 4, Message: "run.skip-files is not allowed"},
+			{Line: 10, Message: "linters-settings.custom is not allowed"},
+			{Line: 14, Message: "severity.default-severity is not allowed"},
+		}}, err)
+		require.Equal(t, []string{"govet"}, runner.Options.LinterConfig.Linters.Enable)
+	})
+}
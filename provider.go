@@ -0,0 +1,70 @@
+package golangci_lint_runner
+
+import (
+	"context"
+	"errors"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+)
+
+// States of the commit status.
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+	StatusError   = "error"
+)
+
+// ErrNotSupported is returned by providers for features their SCM lacks.
+var ErrNotSupported = errors.New("not supported")
+
+// ErrNotFound is returned by providers if a repository or file does not exist.
+var ErrNotFound = errors.New("not found")
+
+// statusContext is the name of the commit status.
+const statusContext = "golangci-lint"
+
+// Provider is the source code management system that hosts the pull (or merge) request, e.g. GitHub or GitLab.
+type Provider interface {
+	// Meta returns the meta data of the pull request.
+	Meta(ctx context.Context) (MetaData, error)
+	// Auth returns the credentials to clone and push the repositories of the pull request.
+	Auth() transport.AuthMethod
+	// Diff returns the unified diff of the pull request.
+	Diff(ctx context.Context) ([]byte, error)
+	// Comments returns all review comments of the pull request.
+	Comments(ctx context.Context) ([]*ReviewComment, error)
+	// CreateReview posts the review.
+	CreateReview(ctx context.Context, review *Review) error
+	// Threads returns the review threads (discussions) of the pull request.
+	Threads(ctx context.Context) ([]*Thread, error)
+	// ResolveThread marks the thread as resolved.
+	ResolveThread(ctx context.Context, thread *Thread) error
+	// MinimizeThread hides the first comment of the thread as outdated.
+	MinimizeThread(ctx context.Context, thread *Thread) error
+	// ReplyToThread adds a comment to the thread.
+	ReplyToThread(ctx context.Context, thread *Thread, body string) error
+	// SetStatus sets the commit status (StatusPending, StatusSuccess, StatusFailure or StatusError) of the head commit.
+	SetStatus(ctx context.Context, state, description string) error
+	// CreateFixPullRequest opens a pull request with the title and body from branch into the head branch of the
+	// pull request (or returns the open one) and returns a reference to it, e.g. #12.
+	CreateFixPullRequest(ctx context.Context, branch, title, body string) (string, error)
+	// DefaultBranchCommit returns the sha of the head commit of the default branch of the repository owner/name.
+	DefaultBranchCommit(ctx context.Context, owner, name string) (string, error)
+	// File returns the content of path in the repository owner/name at ref.
+	File(ctx context.Context, owner, name, path, ref string) ([]byte, error)
+}
+
+// Thread is a review thread (or discussion) of a pull request.
+type Thread struct {
+	// ID of the thread and CommentID of its first comment, both are provider specific
+	ID        string
+	CommentID string
+	Resolved  bool
+	// Path and Body of the first comment
+	Path      string
+	Body      string
+	Minimized bool
+	// LastBody is the body of the last comment
+	LastBody string
+}
@@ -0,0 +1,247 @@
+package golangci_lint_runner
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/google/go-github/github"
+	"github.com/talon-one/golangci-lint-runner/internal/diff"
+)
+
+const (
+	sideRight = "RIGHT"
+)
+
+// Review is like github.PullRequestReviewRequest, but with line based comments.
+// The go-github version we use only knows about the deprecated position field.
+type Review struct {
+	CommitID *string          `json:"commit_id,omitempty"`
+	Body     *string          `json:"body,omitempty"`
+	Event    *string          `json:"event,omitempty"`
+	Comments []*ReviewComment `json:"comments,omitempty"`
+}
+
+// ReviewComment is a review comment that is attached to a line (or a range of lines) instead of a position.
+type ReviewComment struct {
+	ID        *int64  `json:"id,omitempty"`
+	Path      *string `json:"path,omitempty"`
+	Body      *string `json:"body,omitempty"`
+	Line      *int    `json:"line,omitempty"`
+	Side      *string `json:"side,omitempty"`
+	StartLine *int    `json:"start_line,omitempty"`
+	StartSide *string `json:"start_side,omitempty"`
+
+	// fingerprint of the issue, only set for comments we are about to create
+	fingerprint string
+	severity    string
+	issue       *result.Issue
+}
+
+func (r *Review) GetCommitID() string {
+	if r == nil || r.CommitID == nil {
+		return ""
+	}
+	return *r.CommitID
+}
+
+func (r *Review) GetBody() string {
+	if r == nil || r.Body == nil {
+		return ""
+	}
+	return *r.Body
+}
+
+func (r *Review) GetEvent() string {
+	if r == nil || r.Event == nil {
+		return ""
+	}
+	return *r.Event
+}
+
+func (c *ReviewComment) GetID() int64 {
+	if c == nil || c.ID == nil {
+		return 0
+	}
+	return *c.ID
+}
+
+func (c *ReviewComment) GetPath() string {
+	if c == nil || c.Path == nil {
+		return ""
+	}
+	return *c.Path
+}
+
+func (c *ReviewComment) GetBody() string {
+	if c == nil || c.Body == nil {
+		return ""
+	}
+	return *c.Body
+}
+
+func (c *ReviewComment) GetLine() int {
+	if c == nil || c.Line == nil {
+		return 0
+	}
+	return *c.Line
+}
+
+func (c *ReviewComment) GetStartLine() int {
+	if c == nil || c.StartLine == nil {
+		return 0
+	}
+	return *c.StartLine
+}
+
+// commentLines returns the lines of the patched file a comment for the issue should be attached to.
+// start is 0 if the comment spans only one line.
+// ok is false if none of the lines of the issue were added in the patch.
+func commentLines(f *diff.File, issue *result.Issue) (start, end int, ok bool) {
+	r := issue.GetLineRange()
+	for i := r.From; i <= r.To; i++ {
+		line, found := f.LineAt(i)
+		if !found || line.Kind != diff.Added {
+			continue
+		}
+		// multi line comments must be inside one hunk
+		h := f.HunkAt(i)
+		start, end = r.From, r.To
+		if start < h.NewStart {
+			start = h.NewStart
+		}
+		if end > h.NewEnd() {
+			end = h.NewEnd()
+		}
+		if start == end {
+			start = 0
+		}
+		return start, end, true
+	}
+	return 0, 0, false
+}
+
+// makeComment creates the review comment for the issue, nil is returned if the issue is not part of the patch.
+func makeComment(patch *diff.Diff, issue *result.Issue, text, fingerprint string) *ReviewComment {
+	f := patch.File(issue.FilePath())
+	if f == nil {
+		return nil
+	}
+	start, end, ok := commentLines(f, issue)
+	if !ok {
+		return nil
+	}
+	body := text
+	// a suggestion replaces all lines the comment is attached to, so only suggest if we cover the whole issue
+	if r := issue.GetLineRange(); (start == r.From || start == 0 && end == r.From) && end == r.To {
+		if s, ok := suggestion(issue); ok {
+			body += "\n\n" + s
+		}
+	}
+	body += "\n\n" + fingerprintMarker(fingerprint)
+	comment := ReviewComment{
+		Path:        github.String(issue.FilePath()),
+		Body:        github.String(body),
+		Line:        github.Int(end),
+		Side:        github.String(sideRight),
+		fingerprint: fingerprint,
+	}
+	if start > 0 {
+		comment.StartLine = github.Int(start)
+		comment.StartSide = github.String(sideRight)
+	}
+	return &comment
+}
+
+// suggestion renders the replacement of the issue as a github suggestion block.
+func suggestion(issue *result.Issue) (string, bool) {
+	if issue.Replacement == nil {
+		return "", false
+	}
+
+	var lines []string
+	switch {
+	case issue.Replacement.NeedOnlyDelete:
+		// an empty suggestion removes the lines
+	case issue.Replacement.Inline != nil:
+		r := issue.GetLineRange()
+		if r.From != r.To || len(issue.SourceLines) != 1 {
+			return "", false
+		}
+		inline := issue.Replacement.Inline
+		line := issue.SourceLines[0]
+		if inline.StartCol < 0 || inline.Length < 0 || inline.StartCol+inline.Length > len(line) {
+			return "", false
+		}
+		lines = []string{line[:inline.StartCol] + inline.NewString + line[inline.StartCol+inline.Length:]}
+	case issue.Replacement.NewLines != nil:
+		lines = issue.Replacement.NewLines
+	default:
+		return "", false
+	}
+
+	content := strings.Join(lines, "\n")
+	fence := codeFence(content)
+	var sb strings.Builder
+	sb.WriteString(fence)
+	sb.WriteString("suggestion\n")
+	if len(lines) > 0 {
+		sb.WriteString(content)
+		sb.WriteRune('\n')
+	}
+	sb.WriteString(fence)
+	return sb.String(), true
+}
+
+// codeFence returns a backtick fence that is longer than any backtick sequence in content.
+func codeFence(content string) string {
+	longest, current := 0, 0
+	for _, r := range content {
+		if r != '`' {
+			current = 0
+			continue
+		}
+		current++
+		if current > longest {
+			longest = current
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// capComments keeps at most max comments and at most maxPerFile comments per file (0 means unlimited),
+// errors are kept before warnings. The order of the comments is preserved.
+func capComments(comments []*ReviewComment, max, maxPerFile int) (kept, overflow []*ReviewComment) {
+	order := make([]int, len(comments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return severityRank(comments[order[a]].severity) < severityRank(comments[order[b]].severity)
+	})
+
+	keep := make([]bool, len(comments))
+	perFile := make(map[string]int)
+	n := 0
+	for _, i := range order {
+		path := comments[i].GetPath()
+		if (max > 0 && n >= max) || (maxPerFile > 0 && perFile[path] >= maxPerFile) {
+			continue
+		}
+		keep[i] = true
+		perFile[path]++
+		n++
+	}
+
+	for i, comment := range comments {
+		if keep[i] {
+			kept = append(kept, comment)
+		} else {
+			overflow = append(overflow, comment)
+		}
+	}
+	return kept, overflow
+}
@@ -0,0 +1,273 @@
+package golangci_lint_runner
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+	"github.com/talon-one/golangci-lint-runner/internal/diff"
+)
+
+const testPatch = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,8 @@
+ package main
+
++import "fmt"
++
+ func main() {
++	fmt.Println("hello")
++	fmt.Println("world")
+ }
+@@ -20,2 +23,3 @@ func main() {
+ func a() {
++	return
+ }
+`
+
+const testFingerprint = "0123456789abcdef"
+
+func TestMakeComment(t *testing.T) {
+	patch, err := diff.Parse(strings.NewReader(testPatch))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		issue  result.Issue
+		expect *ReviewComment
+	}{
+		{
+			name: "single added line",
+			issue: result.Issue{
+				Text: "unused",
+				Pos:  token.Position{Filename: "main.go", Line: 3},
+			},
+			expect: &ReviewComment{
+				Path:        github.String("main.go"),
+				Body:        github.String("unused\n\n" + fingerprintMarker(testFingerprint)),
+				Line:        github.Int(3),
+				Side:        github.String(sideRight),
+				fingerprint: testFingerprint,
+			},
+		},
+		{
+			name: "context line",
+			issue: result.Issue{
+				Text: "unused",
+				Pos:  token.Position{Filename: "main.go", Line: 5},
+			},
+			expect: nil,
+		},
+		{
+			name: "unknown file",
+			issue: result.Issue{
+				Text: "unused",
+				Pos:  token.Position{Filename: "other.go", Line: 3},
+			},
+			expect: nil,
+		},
+		{
+			name: "range of added lines",
+			issue: result.Issue{
+				Text:      "duplicate",
+				Pos:       token.Position{Filename: "main.go", Line: 6},
+				LineRange: &result.Range{From: 6, To: 7},
+			},
+			expect: &ReviewComment{
+				Path:        github.String("main.go"),
+				Body:        github.String("duplicate\n\n" + fingerprintMarker(testFingerprint)),
+				Line:        github.Int(7),
+				Side:        github.String(sideRight),
+				StartLine:   github.Int(6),
+				StartSide:   github.String(sideRight),
+				fingerprint: testFingerprint,
+			},
+		},
+		{
+			name: "range including context lines",
+			issue: result.Issue{
+				Text:      "funlen",
+				Pos:       token.Position{Filename: "main.go", Line: 5},
+				LineRange: &result.Range{From: 5, To: 8},
+			},
+			expect: &ReviewComment{
+				Path:        github.String("main.go"),
+				Body:        github.String("funlen\n\n" + fingerprintMarker(testFingerprint)),
+				Line:        github.Int(8),
+				Side:        github.String(sideRight),
+				StartLine:   github.Int(5),
+				StartSide:   github.String(sideRight),
+				fingerprint: testFingerprint,
+			},
+		},
+		{
+			name: "range exceeding hunk",
+			issue: result.Issue{
+				Text:      "funlen",
+				Pos:       token.Position{Filename: "main.go", Line: 10},
+				LineRange: &result.Range{From: 10, To: 30},
+			},
+			expect: &ReviewComment{
+				Path:        github.String("main.go"),
+				Body:        github.String("funlen\n\n" + fingerprintMarker(testFingerprint)),
+				Line:        github.Int(25),
+				Side:        github.String(sideRight),
+				StartLine:   github.Int(23),
+				StartSide:   github.String(sideRight),
+				fingerprint: testFingerprint,
+			},
+		},
+		{
+			name: "multi line replacement",
+			issue: result.Issue{
+				Text:      "File is not `gofmt`-ed",
+				Pos:       token.Position{Filename: "main.go", Line: 6},
+				LineRange: &result.Range{From: 6, To: 7},
+				Replacement: &result.Replacement{
+					NewLines: []string{"\tfmt.Println(\"hello world\")"},
+				},
+			},
+			expect: &ReviewComment{
+				Path:        github.String("main.go"),
+				Body:        github.String("File is not `gofmt`-ed\n\n```suggestion\n\tfmt.Println(\"hello world\")\n```\n\n" + fingerprintMarker(testFingerprint)),
+				Line:        github.Int(7),
+				Side:        github.String(sideRight),
+				StartLine:   github.Int(6),
+				StartSide:   github.String(sideRight),
+				fingerprint: testFingerprint,
+			},
+		},
+		{
+			name: "replacement of a clamped range",
+			issue: result.Issue{
+				Text:      "File is not `gofmt`-ed",
+				Pos:       token.Position{Filename: "main.go", Line: 24},
+				LineRange: &result.Range{From: 24, To: 26},
+				Replacement: &result.Replacement{
+					NewLines: []string{"\treturn"},
+				},
+			},
+			expect: &ReviewComment{
+				Path:        github.String("main.go"),
+				Body:        github.String("File is not `gofmt`-ed\n\n" + fingerprintMarker(testFingerprint)),
+				Line:        github.Int(25),
+				Side:        github.String(sideRight),
+				StartLine:   github.Int(24),
+				StartSide:   github.String(sideRight),
+				fingerprint: testFingerprint,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, makeComment(patch, &tt.issue, tt.issue.Text, testFingerprint))
+		})
+	}
+}
+
+func TestSuggestion(t *testing.T) {
+	tests := []struct {
+		name   string
+		issue  result.Issue
+		expect string
+		ok     bool
+	}{
+		{
+			name:  "no replacement",
+			issue: result.Issue{},
+			ok:    false,
+		},
+		{
+			name: "delete",
+			issue: result.Issue{
+				Pos:         token.Position{Line: 3},
+				Replacement: &result.Replacement{NeedOnlyDelete: true},
+			},
+			expect: "```suggestion\n```",
+			ok:     true,
+		},
+		{
+			name: "new lines",
+			issue: result.Issue{
+				Pos:         token.Position{Line: 3},
+				LineRange:   &result.Range{From: 3, To: 4},
+				Replacement: &result.Replacement{NewLines: []string{"a", "b", "c"}},
+			},
+			expect: "```suggestion\na\nb\nc\n```",
+			ok:     true,
+		},
+		{
+			name: "inline",
+			issue: result.Issue{
+				Pos:         token.Position{Line: 3},
+				SourceLines: []string{"// recieve a value"},
+				Replacement: &result.Replacement{Inline: &result.InlineFix{StartCol: 3, Length: 7, NewString: "receive"}},
+			},
+			expect: "```suggestion\n// receive a value\n```",
+			ok:     true,
+		},
+		{
+			name: "inline out of range",
+			issue: result.Issue{
+				Pos:         token.Position{Line: 3},
+				SourceLines: []string{"// recieve"},
+				Replacement: &result.Replacement{Inline: &result.InlineFix{StartCol: 3, Length: 70, NewString: "receive"}},
+			},
+			ok: false,
+		},
+		{
+			name: "content with backticks",
+			issue: result.Issue{
+				Pos:         token.Position{Line: 3},
+				Replacement: &result.Replacement{NewLines: []string{"s := `", "```", "`"}},
+			},
+			expect: "````suggestion\ns := `\n```\n`\n````",
+			ok:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, ok := suggestion(&tt.issue)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.expect, s)
+		})
+	}
+}
+
+func TestCapComments(t *testing.T) {
+	comment := func(path, severity string) *ReviewComment {
+		return &ReviewComment{Path: github.String(path), severity: severity}
+	}
+	a1 := comment("a.go", SeverityWarning)
+	a2 := comment("a.go", SeverityError)
+	a3 := comment("a.go", SeverityError)
+	b1 := comment("b.go", SeverityWarning)
+	c1 := comment("c.go", SeverityError)
+	comments := []*ReviewComment{a1, a2, a3, b1, c1}
+
+	tests := []struct {
+		name       string
+		max        int
+		maxPerFile int
+		kept       []*ReviewComment
+		overflow   []*ReviewComment
+	}{
+		{name: "unlimited", kept: comments},
+		{name: "max", max: 3, kept: []*ReviewComment{a2, a3, c1}, overflow: []*ReviewComment{a1, b1}},
+		{name: "max per file", maxPerFile: 1, kept: []*ReviewComment{a2, b1, c1}, overflow: []*ReviewComment{a1, a3}},
+		{name: "both", max: 3, maxPerFile: 2, kept: []*ReviewComment{a2, a3, c1}, overflow: []*ReviewComment{a1, b1}},
+		{name: "warnings fill up", max: 4, maxPerFile: 2, kept: []*ReviewComment{a2, a3, b1, c1}, overflow: []*ReviewComment{a1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, overflow := capComments(comments, tt.max, tt.maxPerFile)
+			require.Equal(t, tt.kept, kept)
+			require.Equal(t, tt.overflow, overflow)
+		})
+	}
+}
@@ -1,38 +1,39 @@
 package golangci_lint_runner
 
 import (
+	"bytes"
 	"fmt"
 
 	"context"
 	"errors"
-	"net/http"
+	"io"
 	"os"
 
 	"io/ioutil"
 
 	"path/filepath"
+	"strings"
 
-	"crypto/rsa"
-	"strconv"
 	"time"
 
-	"strings"
-
 	"encoding/json"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/golangci/golangci-lint/pkg/config"
 	"github.com/golangci/golangci-lint/pkg/report"
 	"github.com/google/go-github/github"
-	"github.com/imdario/mergo"
 	"github.com/spf13/viper"
 	"github.com/talon-one/golangci-lint-runner/internal"
+	"github.com/talon-one/golangci-lint-runner/internal/diff"
+	"github.com/talon-one/golangci-lint-runner/internal/export"
 	"gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
-	gitHttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
 type Options struct {
+	// Provider hosts the pull request, if it is nil a GitHubProvider is created from Client, CloneToken,
+	// PullRequest (or Owner, Name and PullRequestNumber)
+	Provider          Provider
 	Client            *github.Client
 	CloneToken        string
 	Context           context.Context
@@ -47,12 +48,60 @@ type Options struct {
 	Approve           bool
 	RequestChanges    bool
 	DryRun            bool
-	// NoChangesText sends the text when no go code changes are present
-	NoChangesText   string
-	NoIssuesText    string
+	// Autofix runs golangci-lint --fix for the AutofixLinters and pushes the result instead of commenting
+	Autofix        bool
+	AutofixLinters []string
+	// OutdatedComments specifies what to do with comments of previous runs that are no longer reported
+	// (OutdatedCommentsKeep, OutdatedCommentsResolve, OutdatedCommentsMinimize or OutdatedCommentsReply)
+	OutdatedComments string
+	// MaxComments and MaxCommentsPerFile limit the inline comments of a review (0 is unlimited),
+	// the other issues are listed in the summary
+	MaxComments        int
+	MaxCommentsPerFile int
+	// Reports maps a report format (see ReportFormats) to the file the issues in the changed lines are written to,
+	// reports are also written on a dry run
+	Reports map[string]string
+	// Templates for the posted messages, empty templates fall back to DefaultTemplates
+	Templates Templates
+	// Deprecated: use Templates.NoChanges, the text is used if the template is not set.
+	NoChangesText string
+	// Deprecated: use Templates.NoIssues, the text is used if the template is not set.
+	NoIssuesText string
+	// Deprecated: use Templates.NoNewIssues, the text is used if the template is not set.
 	NoNewIssuesText string
+	// Status sets a commit status on the head commit while and after linting
+	Status bool
+	// OrgConfig is the location (owner/repo/path) of a shared config that is merged into LinterConfig before the
+	// config of the repository, OrgConfigOwner is replaced with the owner of the repository, e.g. {owner}/.github/golangci.yml
+	OrgConfig string
+	// OrgConfigCache caches the org config by commit, a new cache is created if it is nil
+	OrgConfigCache *OrgConfigCache
+	// MergeStrategies maps dot separated config settings (e.g. linters.enable) to the strategy (MergeReplace,
+	// MergeAppend or MergeRemove) used to merge them from the org and repository config, the default is MergeReplace.
+	// Config files can override them in the golangci-lint-runner.merge section.
+	MergeStrategies map[string]string
+	// ConfigPolicy lists the dot separated settings (or sections) the config of the repository may set, a leading !
+	// forbids a setting and * matches all settings, the most specific entry wins (e.g. linters-settings and
+	// !linters-settings.custom). Forbidden settings are ignored and listed in the summary. NewRunner sets
+	// DefaultConfigPolicy if it is nil.
+	ConfigPolicy []string
+	// RejectForbiddenSettings fails the run with a *ConfigError instead of ignoring forbidden settings
+	RejectForbiddenSettings bool
+	// LinterDir contains installed golangci-lint versions as <version>/golangci-lint (e.g. 1.31.0/golangci-lint),
+	// repositories require a version with golangci-lint-runner.version in their config or a LinterVersionFile.
+	// The golangci-lint of the PATH is used if no version is required or it is not installed.
+	LinterDir string
+	// ConfigSource is the commit the config of the repository is read from, ConfigSourceHead (the default) or
+	// ConfigSourceBase, which prevents pull requests from changing the config they are linted with
+	ConfigSource string
 }
 
+// Commits the config of the repository can be read from.
+const (
+	ConfigSourceHead = "head"
+	ConfigSourceBase = "base"
+)
+
 type BranchMeta struct {
 	OwnerName string
 	RepoName  string
@@ -68,26 +117,43 @@ type MetaData struct {
 	Head              BranchMeta
 	PullRequestNumber int
 	PullRequestURL    string
-	InstallationID    int64
+	// Reference of the pull request in texts, e.g. #12 (or !12 for GitLab merge requests)
+	Reference      string
+	InstallationID int64
 }
 
 type Runner struct {
-	meta    MetaData
-	Options *Options
+	meta      MetaData
+	Options   *Options
+	provider  Provider
+	templates *templates
+	// ignoredSettings of the repository config that the config policy does not allow
+	ignoredSettings []string
+	// unknownSettings of the repository config, golangci-lint ignores them
+	unknownSettings []string
+	// baseCommit is the base commit in local mode, its files are read with go-git instead of the provider
+	baseCommit *object.Commit
+	// requiredVersion of golangci-lint from the config and linterPath of the selected binary (see selectLinter)
+	requiredVersion string
+	linterPath      string
 }
 
+// Events of a review.
 const (
-	githubEventApprove        = "APPROVE"
-	githubEventRequestChanges = "REQUEST_CHANGES"
-	githubEventComment        = "COMMENT"
+	ReviewEventApprove        = "APPROVE"
+	ReviewEventRequestChanges = "REQUEST_CHANGES"
+	ReviewEventComment        = "COMMENT"
 )
 
 func NewRunner(options Options) (*Runner, error) {
-	if options.Client == nil {
-		return nil, errors.New("Client must be specified")
-	}
-	if options.CloneToken == "" {
-		return nil, errors.New("CloneToken must be specified")
+	if options.Provider == nil {
+		if options.Client == nil {
+			return nil, errors.New("Client must be specified")
+		}
+		if options.CloneToken == "" {
+			return nil, errors.New("CloneToken must be specified")
+		}
+		options.Provider = NewGitHubProvider(options.Client, options.CloneToken, options.Owner, options.Name, options.PullRequestNumber, options.PullRequest)
 	}
 	if options.Context == nil {
 		return nil, errors.New("Context must be specified")
@@ -98,8 +164,42 @@ func NewRunner(options Options) (*Runner, error) {
 	if options.Timeout <= 0 {
 		options.Timeout = time.Minute * 10
 	}
+	if len(options.AutofixLinters) == 0 {
+		options.AutofixLinters = DefaultAutofixLinters
+	}
+	if options.OrgConfigCache == nil {
+		options.OrgConfigCache = NewOrgConfigCache()
+	}
+	switch options.OutdatedComments {
+	case "", OutdatedCommentsKeep, OutdatedCommentsResolve, OutdatedCommentsMinimize, OutdatedCommentsReply:
+	default:
+		return nil, fmt.Errorf("unknown OutdatedComments value %q", options.OutdatedComments)
+	}
+	if options.ConfigPolicy == nil {
+		options.ConfigPolicy = DefaultConfigPolicy
+	}
+	switch options.ConfigSource {
+	case "", ConfigSourceHead, ConfigSourceBase:
+	default:
+		return nil, fmt.Errorf("unknown ConfigSource value %q", options.ConfigSource)
+	}
+	if err := validateMergeStrategies(options.MergeStrategies); err != nil {
+		return nil, err
+	}
+	for format := range options.Reports {
+		if !export.IsFormat(format) {
+			return nil, fmt.Errorf("unknown report format %q", format)
+		}
+	}
+	options.Templates = options.Templates.withTexts(options.NoChangesText, options.NoIssuesText, options.NoNewIssuesText)
+	templates, err := parseTemplates(options.Templates)
+	if err != nil {
+		return nil, fmt.Errorf("invalid templates: %w", err)
+	}
 	runner := Runner{
-		Options: &options,
+		Options:   &options,
+		provider:  options.Provider,
+		templates: templates,
 	}
 
 	if runner.Options.CacheDir == "" {
@@ -112,33 +212,63 @@ func NewRunner(options Options) (*Runner, error) {
 		}
 	}
 
-	if runner.Options.PullRequest == nil {
-		var err error
-		runner.Options.Logger.Debug("getting pull request")
-		runner.Options.PullRequest, _, err = runner.Options.Client.PullRequests.Get(runner.Options.Context, runner.Options.Owner, runner.Options.Name, runner.Options.PullRequestNumber)
-		if err != nil {
-			return nil, internal.WireError{
-				PublicError:  errors.New("unable to get pull request"),
-				PrivateError: fmt.Errorf("unable to get pull request: %w", err),
-			}
+	runner.Options.Logger.Debug("get meta")
+	runner.meta, err = runner.provider.Meta(runner.Options.Context)
+	if err != nil {
+		return nil, internal.WireError{
+			PublicError:  errors.New("unable to get pull request"),
+			PrivateError: err,
 		}
 	}
-
-	if err := runner.getMeta(); err != nil {
-		return nil, err
-	}
+	runner.Options.Logger = withFields(runner.Options.Logger, Fields{
+		"repo":         runner.meta.Base.FullName,
+		"pull_request": runner.meta.PullRequestNumber,
+		"sha":          runner.meta.Head.SHA,
+	})
 
 	return &runner, nil
 }
 
 func (runner *Runner) Run() error {
+	runner.setStatus(StatusPending, "golangci-lint is running")
+	newErrors, err := runner.run()
+	var configErr *ConfigError
+	switch {
+	case errors.As(err, &configErr):
+		runner.setStatus(StatusFailure, fmt.Sprintf("%s is invalid", configErr.File))
+	case err != nil:
+		runner.setStatus(StatusError, "golangci-lint-runner failed")
+	case newErrors > 0:
+		runner.setStatus(StatusFailure, fmt.Sprintf("golangci-lint found %d new errors", newErrors))
+	default:
+		runner.setStatus(StatusSuccess, "golangci-lint found no new errors")
+	}
+	return err
+}
+
+// setStatus sets the commit status if enabled, failures are only logged.
+func (runner *Runner) setStatus(state, description string) {
+	if !runner.Options.Status {
+		return
+	}
+	if runner.Options.DryRun {
+		runner.Options.Logger.Info("not setting status %s because of dry run", state)
+		return
+	}
+	if err := runner.provider.SetStatus(runner.Options.Context, state, description); err != nil {
+		runner.Options.Logger.Warn("unable to set status %s: %s", state, err)
+	}
+}
+
+// run lints the pull request and returns the number of new errors.
+func (runner *Runner) run() (int, error) {
 	// prepare work directory
 	startTime := time.Now()
 	runner.Options.Logger.Info("starting with pull request %s", runner.meta.PullRequestURL)
 	runner.Options.Logger.Debug("preparing work directory")
 	workDir, err := ioutil.TempDir("", "golangci-lint-runner-work-")
 	if err != nil {
-		return fmt.Errorf("unable to create work directory: %w", err)
+		return 0, fmt.Errorf("unable to create work directory: %w", err)
 	}
 	// remove work directory on end
 	defer func() {
@@ -173,47 +303,84 @@ func (runner *Runner) Run() error {
 	// todo: replace github.com with some response from api
 	repoDir := filepath.Join(workDir, "src", "github.com", runner.meta.Head.FullName)
 	if err := os.MkdirAll(repoDir, 0744); err != nil {
-		return fmt.Errorf("unable to create repo %s directory: %w", repoDir, err)
+		return 0, fmt.Errorf("unable to create repo %s directory: %w", repoDir, err)
 	}
 	runner.Options.Logger.Debug("repo directory is %s", repoDir)
 
 	if err := runner.clone(repoDir); err != nil {
-		return err
+		return 0, err
 	}
 
+	configFile := runner.Options.LinterConfig.Run.Config
 	if err := runner.readRepoConfig(repoDir); err != nil {
-		return err
+		var configErr *ConfigError
+		if errors.As(err, &configErr) {
+			if err := runner.reportConfigError(configErr); err != nil {
+				runner.Options.Logger.Warn("unable to report invalid config: %s", err)
+			}
+		}
+		return 0, err
 	}
 
-	patchFile := filepath.Join(workDir, "patch")
-	if err := runner.downloadPatch(patchFile); err != nil {
-		return err
+	unavailableVersion := runner.selectLinter(repoDir)
+
+	runner.Options.Logger.Debug("downloading patch")
+	buf, err := runner.provider.Diff(runner.Options.Context)
+	if err != nil {
+		return 0, fmt.Errorf("unable to download patch: %w", err)
+	}
+
+	patch, err := diff.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse patch: %w", err)
 	}
 
-	reviewRequest := github.PullRequestReviewRequest{
+	reviewRequest := Review{
 		CommitID: github.String(runner.meta.Head.SHA),
 	}
 
-	goCode, err := hasGoCode(patchFile)
-	if err != nil {
-		return fmt.Errorf("unable to detect go code: %w", err)
+	var configChanged string
+	if patch.File(configFile) != nil {
+		configChanged = configFile
+		runner.Options.Logger.Info("pull request changes %s (config source %s)", configFile, runner.configSource())
 	}
-	if !goCode {
+
+	if !hasGoCode(patch) {
 		runner.Options.Logger.Debug("no go code present")
-		reviewRequest.Body = github.String(runner.Options.NoChangesText)
+		if err := runner.writeReports(repoDir, nil, ""); err != nil {
+			return 0, err
+		}
+		summary := Summary{
+			PullRequest:        runner.meta,
+			ConfigChanged:      configChanged,
+			ConfigSource:       runner.configSource(),
+			IgnoredSettings:    runner.ignoredSettings,
+			UnknownSettings:    runner.unknownSettings,
+			UnavailableVersion: unavailableVersion,
+		}
+		body, err := execute(runner.templates.noChanges, &summary)
+		if err != nil {
+			return 0, err
+		}
+		reviewRequest.Body = github.String(body)
 		if runner.Options.Approve {
-			reviewRequest.Event = github.String(githubEventApprove)
+			reviewRequest.Event = github.String(ReviewEventApprove)
 		} else {
-			reviewRequest.Event = github.String(githubEventComment)
+			reviewRequest.Event = github.String(ReviewEventComment)
+		}
+		if err := runner.handleOutdatedComments(nil); err != nil {
+			runner.Options.Logger.Warn("unable to handle outdated comments: %s", err)
 		}
-		return runner.sendReview(&reviewRequest)
+		return 0, runner.sendReview(&reviewRequest)
 	}
 
 	result, err := runner.runLinter(runner.Options.CacheDir, workDir, repoDir)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
+	linterVersion := runner.linterVersion(workDir, repoDir)
+
 	var warnings []report.Warning
 	if result.Report != nil {
 		warnings = result.Report.Warnings
@@ -226,91 +393,136 @@ func (runner *Runner) Run() error {
 	// 	fmt.Printf("%s:%d: %s (from %s)\n", issue.FilePath(), issue.Line(), issue.Text, issue.FromLinter)
 	// }
 
-	result.Issues, err = filterIssues(patchFile, result.Issues)
-	if err != nil {
-		return err
+	filteredIssues, sameFileIssues, otherFileIssues := filterIssues(patch, result.Issues)
+	result.Issues = filteredIssues
+
+	var autofixText string
+	if runner.Options.Autofix {
+		runner.Options.Logger.Debug("fixing issues")
+		text, remaining, err := runner.autofix(workDir, repoDir, patch, result)
+		if err != nil {
+			runner.Options.Logger.Warn("unable to fix issues: %s", err)
+		} else {
+			autofixText = text
+			result.Issues = remaining
+		}
+		// the fixes are not part of the head commit (or only pushed on top of it), remove them before the
+		// fingerprints and reports read the files
+		if err := runner.resetWorkTree(repoDir); err != nil {
+			return 0, err
+		}
 	}
 
-	for i := range result.Issues {
-		if runner.Options.LinterConfig.Output.PrintLinterName {
-			result.Issues[i].Text += fmt.Sprintf(" (from %s)", result.Issues[i].FromLinter)
+	if err := runner.writeReports(repoDir, result.Issues, linterVersion); err != nil {
+		return 0, err
+	}
+
+	// info issues are only listed in the summary
+	issues, infoIssues := splitInfoIssues(result.Issues)
+
+	// fingerprints must be calculated before the text is changed
+	fingerprints := issueFingerprints(repoDir, issues)
+	if runner.Options.LinterConfig.Output.PrintLinterName {
+		appendLinterName(issues)
+		appendLinterName(infoIssues)
+		appendLinterName(sameFileIssues)
+		appendLinterName(otherFileIssues)
+	}
+	for i := range issues {
+		issue := &issues[i]
+		severity := issueSeverity(issue)
+		text, err := execute(runner.templates.comment, &CommentData{PullRequest: runner.meta, Issue: issue, Severity: severity})
+		if err != nil {
+			return 0, err
+		}
+		if comment := makeComment(patch, issue, text, fingerprints[i]); comment != nil {
+			comment.severity = severity
+			comment.issue = issue
+			reviewRequest.Comments = append(reviewRequest.Comments, comment)
 		}
+	}
 
-		reviewRequest.Comments = append(reviewRequest.Comments, &github.DraftReviewComment{
-			Path:     github.String(result.Issues[i].FilePath()),
-			Position: github.Int(result.Issues[i].HunkPos),
-			Body:     github.String(result.Issues[i].Text),
-		})
+	if err := runner.handleOutdatedComments(reviewRequest.Comments); err != nil {
+		runner.Options.Logger.Warn("unable to handle outdated comments: %s", err)
 	}
 
 	totalComments := len(reviewRequest.Comments)
 	runner.Options.Logger.Debug("filtering comments %d", len(reviewRequest.Comments))
 	if err := runner.filterComments(&reviewRequest); err != nil {
-		return fmt.Errorf("unable to filter comments: %w", err)
+		return 0, fmt.Errorf("unable to filter comments: %w", err)
 	}
 	newComments := len(reviewRequest.Comments)
 	runner.Options.Logger.Debug("filtered comments down to %d", newComments)
 
-	runner.Options.Logger.Info("golangci-lint reported %d issues (%d issues are new) and %d warnings for %s", totalComments, newComments, len(warnings), runner.meta.Head.FullName)
-
-	passing := false
-
-	if newComments > 0 {
-		if totalComments != newComments {
-			reviewRequest.Body = github.String(fmt.Sprintf("golangci-lint found %d new issues", newComments))
-		} else {
-			reviewRequest.Body = github.String(fmt.Sprintf("golangci-lint found %d issues", newComments))
-		}
-		passing = false
-	} else {
-		if totalComments != newComments {
-			reviewRequest.Body = github.String(runner.Options.NoIssuesText)
-		} else {
-			reviewRequest.Body = github.String(runner.Options.NoNewIssuesText)
+	var newErrors int
+	for _, comment := range reviewRequest.Comments {
+		if comment.severity == SeverityError {
+			newErrors++
 		}
-		passing = true
 	}
 
-	if len(warnings) > 0 {
-		passing = false
-		var sb strings.Builder
-		if *reviewRequest.Body != "" {
-			sb.WriteString(*reviewRequest.Body)
-			sb.WriteString(", but ")
-		}
-		fmt.Fprintf(&sb, "got %d warnings:", len(warnings))
-		sb.WriteString("<code>")
-		for _, w := range warnings {
-			fmt.Fprintf(&sb, "%s: %s\n", w.Tag, strings.TrimSpace(w.Text))
-		}
-		sb.WriteString("</code>")
-		reviewRequest.Body = github.String(sb.String())
+	runner.Options.Logger.Info("golangci-lint reported %d issues (%d issues are new, %d are errors), %d info issues and %d warnings for %s", totalComments, newComments, newErrors, len(infoIssues), len(warnings), runner.meta.Head.FullName)
+
+	var overflow []*ReviewComment
+	reviewRequest.Comments, overflow = capComments(reviewRequest.Comments, runner.Options.MaxComments, runner.Options.MaxCommentsPerFile)
+	if len(overflow) > 0 {
+		runner.Options.Logger.Debug("not commenting %d issues because of the comment limits", len(overflow))
+	}
+
+	summary := newSummary(issues, result.Report)
+	summary.Info = infoIssues
+	summary.SameFile = sameFileIssues
+	summary.OtherFiles = otherFileIssues
+	for _, comment := range overflow {
+		summary.Overflow = append(summary.Overflow, *comment.issue)
+	}
+	summary.PullRequest = runner.meta
+	summary.NewIssues = newComments
+	summary.Autofix = autofixText
+	summary.ConfigChanged = configChanged
+	summary.ConfigSource = runner.configSource()
+	summary.IgnoredSettings = runner.ignoredSettings
+	summary.UnknownSettings = runner.unknownSettings
+	summary.UnavailableVersion = unavailableVersion
+	summary.Version = linterVersion
+	summary.Duration = time.Since(startTime).Round(time.Second)
+	title := runner.templates.noIssues
+	switch {
+	case newComments > 0:
+		title = runner.templates.issues
+	case totalComments > 0:
+		title = runner.templates.noNewIssues
+	}
+	if summary.Title, err = execute(title, summary); err != nil {
+		return 0, err
+	}
+	body, err := summary.render(runner.templates.summary)
+	if err != nil {
+		return 0, fmt.Errorf("unable to render summary: %w", err)
 	}
+	reviewRequest.Body = github.String(body)
 
-	if passing {
-		if runner.Options.Approve {
-			reviewRequest.Event = github.String(githubEventApprove)
-		} else {
-			reviewRequest.Event = github.String(githubEventComment)
-		}
-	} else {
-		if runner.Options.RequestChanges {
-			reviewRequest.Event = github.String(githubEventRequestChanges)
-		} else {
-			reviewRequest.Event = github.String(githubEventComment)
-		}
+	switch {
+	case newErrors > 0 && runner.Options.RequestChanges:
+		reviewRequest.Event = github.String(ReviewEventRequestChanges)
+	// a patch that does not compile is never approved
+	case newComments == 0 && len(warnings) == 0 && !hasTypecheckIssue(sameFileIssues) && !hasTypecheckIssue(otherFileIssues) && runner.Options.Approve:
+		reviewRequest.Event = github.String(ReviewEventApprove)
+	default:
+		// warnings are only commented
+		reviewRequest.Event = github.String(ReviewEventComment)
 	}
 
 	if err := runner.sendReview(&reviewRequest); err != nil {
-		return fmt.Errorf("unable to send review: %w", err)
+		return 0, fmt.Errorf("unable to send review: %w", err)
 	}
 	runner.Options.Logger.Debug("finished with %d, took %s", runner.meta.PullRequestNumber, time.Now().Sub(startTime).String())
-	return nil
+	return newErrors, nil
 }
 
-func (runner *Runner) sendReview(reviewRequest *github.PullRequestReviewRequest) error {
+func (runner *Runner) sendReview(reviewRequest *Review) error {
 	// do not send conditions
-	if (*reviewRequest.Event == githubEventRequestChanges || *reviewRequest.Event == githubEventComment) && (reviewRequest.Body == nil || *reviewRequest.Body == "") {
+	if (*reviewRequest.Event == ReviewEventRequestChanges || *reviewRequest.Event == ReviewEventComment) && (reviewRequest.Body == nil || *reviewRequest.Body == "") {
 		runner.Options.Logger.Debug("not sending review because body is empty and event is either REQUEST_CHANGES or COMMENT")
 		return nil
 	}
@@ -330,77 +542,70 @@ func (runner *Runner) sendReview(reviewRequest *github.PullRequestReviewRequest)
 		return nil
 	}
 
-	_, _, err = runner.Options.Client.PullRequests.CreateReview(runner.Options.Context, runner.meta.Base.OwnerName, runner.meta.Base.RepoName, runner.meta.PullRequestNumber, reviewRequest)
-	if err != nil {
+	if err := runner.provider.CreateReview(runner.Options.Context, reviewRequest); err != nil {
 		return fmt.Errorf("unable to create review %s: %w", string(buf), err)
 	}
 	return nil
 }
 
-func (runner *Runner) filterComments(request *github.PullRequestReviewRequest) error {
-	page := 1
-	for {
-		comments, res, err := runner.Options.Client.PullRequests.ListComments(runner.Options.Context, runner.meta.Base.OwnerName, runner.meta.Base.RepoName, runner.meta.PullRequestNumber, &github.PullRequestListCommentsOptions{
-			ListOptions: github.ListOptions{
-				Page:    page,
-				PerPage: 30,
-			},
-		})
+// filterComments removes all comments that were already posted.
+// Comments are compared by their fingerprint, comments without fingerprint (of older versions) by their position and body.
+func (runner *Runner) filterComments(request *Review) error {
+	fingerprints := make(map[string]struct{})
+	var legacyComments []*ReviewComment
+	comments, err := runner.provider.Comments(runner.Options.Context)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if fingerprint, _ := parseMarker(comment.GetBody()); fingerprint != "" {
+			fingerprints[fingerprint] = struct{}{}
+			continue
+		}
+		legacyComments = append(legacyComments, comment)
+	}
 
-		if err != nil {
-			return err
+	for i := len(request.Comments) - 1; i >= 0; i-- {
+		if _, ok := fingerprints[request.Comments[i].fingerprint]; ok {
+			request.Comments = append(request.Comments[:i], request.Comments[i+1:]...)
 		}
+	}
 
-		for _, comment := range comments {
-			for i := len(request.Comments) - 1; i >= 0; i-- {
-				if request.Comments[i].GetPosition() != comment.GetPosition() {
-					continue
-				}
-				if request.Comments[i].GetPath() != comment.GetPath() {
-					continue
-				}
-				if request.Comments[i].GetBody() != comment.GetBody() {
-					continue
-				}
-				request.Comments = append(request.Comments[:i], request.Comments[i+1:]...)
-				if len(request.Comments) == 0 {
-					return nil
-				}
+	for _, comment := range legacyComments {
+		for i := len(request.Comments) - 1; i >= 0; i-- {
+			if request.Comments[i].GetLine() != comment.GetLine() {
+				continue
 			}
+			if request.Comments[i].GetStartLine() != comment.GetStartLine() {
+				continue
+			}
+			if request.Comments[i].GetPath() != comment.GetPath() {
+				continue
+			}
+			if stripMarker(request.Comments[i].GetBody()) != stripMarker(comment.GetBody()) {
+				continue
+			}
+			request.Comments = append(request.Comments[:i], request.Comments[i+1:]...)
 		}
-		if res.NextPage <= 0 {
-			return nil
-		}
-		page = res.NextPage
 	}
-
 	return nil
 }
 
-func (runner *Runner) downloadPatch(patchFile string) error {
-	runner.Options.Logger.Debug("downloading patch file")
-	s, _, err := runner.Options.Client.PullRequests.GetRaw(context.Background(), runner.meta.Base.OwnerName, runner.meta.Base.RepoName, runner.meta.PullRequestNumber, github.RawOptions{github.Diff})
-	if err != nil {
-		return fmt.Errorf("unable to download patch file: %w", err)
-	}
-
-	return ioutil.WriteFile(patchFile, []byte(s), 0744)
-}
-
 func (runner *Runner) clone(repoDir string) error {
 	branchName := fmt.Sprintf("refs/heads/%s", runner.meta.Head.Ref)
 	runner.Options.Logger.Debug("cloning %s (%s) to %s", runner.meta.Head.CloneURL, branchName, repoDir)
+	depth := 1
+	if runner.Options.Autofix {
+		// go-git can not reliably push commits from shallow clones
+		depth = 0
+	}
 	_, err := git.PlainCloneContext(runner.Options.Context, repoDir, false, &git.CloneOptions{
-		URL: runner.meta.Head.CloneURL,
-		Auth: &gitHttp.BasicAuth{
-			// can be anything expect empty
-			Username: "x-access-token",
-			Password: runner.Options.CloneToken,
-		},
+		URL:               runner.meta.Head.CloneURL,
+		Auth:              runner.provider.Auth(),
 		ReferenceName:     plumbing.ReferenceName(branchName),
 		SingleBranch:      true,
 		NoCheckout:        false,
-		Depth:             1,
+		Depth:             depth,
 		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
 		Tags:              git.NoTags,
 	})
@@ -410,164 +615,165 @@ func (runner *Runner) clone(repoDir string) error {
 	return nil
 }
 
-func (runner *Runner) getMeta() error {
-	runner.Options.Logger.Debug("get meta")
-
-	runner.meta.PullRequestNumber = runner.Options.PullRequest.GetNumber()
-	if runner.meta.PullRequestNumber == 0 {
-		return errors.New("unable to get number from pull request")
-	}
-
-	runner.meta.PullRequestURL = runner.Options.PullRequest.GetHTMLURL()
-	if runner.meta.PullRequestURL == "" {
-		return errors.New("unable to get url from pull request")
+// readRepoConfig merges the org config (see readOrgConfig) and the config file of the repository (of the head or
+// the base commit, see Options.ConfigSource) into the linter config.
+func (r *Runner) readRepoConfig(repoDir string) error {
+	if err := r.readOrgConfig(); err != nil {
+		return err
 	}
 
-	var err error
-	base := runner.Options.PullRequest.GetBase()
-	if base == nil {
-		return errors.New("unable to get base")
-	}
-	runner.meta.Base, err = runner.getBranchMeta(base)
-	if err != nil {
-		return fmt.Errorf("unable to get branch meta for base: %w", err)
+	if r.configSource() == ConfigSourceBase {
+		return r.readBaseConfig()
 	}
 
-	head := runner.Options.PullRequest.GetHead()
-	if head == nil {
-		return errors.New("unable to get head")
-	}
-	runner.meta.Head, err = runner.getBranchMeta(head)
+	p := filepath.Join(repoDir, r.Options.LinterConfig.Run.Config)
+	r.Options.Logger.Debug("trying to read linter config file %s", p)
+	file, err := os.Open(p)
 	if err != nil {
-		return fmt.Errorf("unable to get branch meta for head: %w", err)
+		if os.IsNotExist(err) {
+			r.Options.Logger.Debug("no config file present")
+			return nil
+		}
+		return fmt.Errorf("unable to open %s: %w", r.Options.LinterConfig.Run.Config, err)
 	}
+	defer file.Close()
 
-	return nil
+	return r.applyConfig(file, r.Options.LinterConfig.Run.Config, true)
 }
 
-func (Runner) getBranchMeta(branch *github.PullRequestBranch) (BranchMeta, error) {
-	sha := branch.GetSHA()
-	if sha == "" {
-		return BranchMeta{}, errors.New("unable to get sha")
+// applyPolicy rejects the forbidden settings (with Options.RejectForbiddenSettings) or returns v without them.
+func (r *Runner) applyPolicy(v *viper.Viper, name string, forbidden []forbiddenSetting) (*viper.Viper, error) {
+	if r.Options.RejectForbiddenSettings {
+		configErr := &ConfigError{File: name}
+		for _, setting := range forbidden {
+			configErr.Problems = append(configErr.Problems, ConfigProblem{Line: setting.line, Message: fmt.Sprintf("%s is not allowed", setting.path)})
+		}
+		return nil, configErr
 	}
 
-	ref := branch.GetRef()
-	if ref == "" {
-		return BranchMeta{}, errors.New("unable to get ref")
+	settings := v.AllSettings()
+	for _, setting := range forbidden {
+		r.Options.Logger.Warn("ignoring %s (line %d) of %s, it is not allowed by the config policy", setting.path, setting.line, name)
+		r.ignoredSettings = append(r.ignoredSettings, setting.path)
+		deleteSetting(settings, setting.path)
 	}
-
-	repo := branch.GetRepo()
-	if repo == nil {
-		return BranchMeta{}, errors.New("unable to get repo")
+	allowed := viper.New()
+	if err := allowed.MergeConfigMap(settings); err != nil {
+		return nil, configError(name, err)
 	}
+	return allowed, nil
+}
 
-	name := repo.GetName()
-	if name == "" {
-		return BranchMeta{}, errors.New("unable to get repo name")
+// configSource returns the commit the config of the repository is read from.
+func (r *Runner) configSource() string {
+	if r.Options.ConfigSource == ConfigSourceBase {
+		return ConfigSourceBase
 	}
+	return ConfigSourceHead
+}
 
-	fullName := repo.GetFullName()
-	if fullName == "" {
-		return BranchMeta{}, errors.New("unable to get repo fullname")
+// readBaseConfig merges the config file of the base commit into the linter config.
+func (r *Runner) readBaseConfig() error {
+	name := r.Options.LinterConfig.Run.Config
+	r.Options.Logger.Debug("trying to read linter config file %s of %s", name, r.meta.Base.SHA)
+	content, err := r.baseFile(name)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			r.Options.Logger.Debug("no config file present in %s", r.meta.Base.SHA)
+			return nil
+		}
+		return fmt.Errorf("unable to download %s of %s: %w", name, r.meta.Base.SHA, err)
 	}
+	return r.applyConfig(bytes.NewReader(content), name, true)
+}
 
-	cloneURL := repo.GetCloneURL()
-	if cloneURL == "" {
-		return BranchMeta{}, errors.New("unable to get repo clone url")
+// baseFile returns the content of path in the base commit, the error wraps ErrNotFound if the file does not exist.
+func (r *Runner) baseFile(path string) ([]byte, error) {
+	if r.baseCommit == nil {
+		return r.provider.File(r.Options.Context, r.meta.Base.OwnerName, r.meta.Base.RepoName, path, r.meta.Base.SHA)
 	}
-
-	owner := repo.GetOwner()
-	if owner == nil {
-		return BranchMeta{}, errors.New("unable to get repo owner")
+	file, err := r.baseCommit.File(path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, fmt.Errorf("file %s: %w", path, ErrNotFound)
 	}
-
-	login := owner.GetLogin()
-	if login == "" {
-		return BranchMeta{}, errors.New("unable to get owner login name")
+	if err != nil {
+		return nil, err
 	}
-
-	return BranchMeta{
-		OwnerName: login,
-		RepoName:  name,
-		FullName:  fullName,
-		CloneURL:  cloneURL,
-		Ref:       ref,
-		SHA:       sha,
-	}, nil
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
 }
 
-func (r *Runner) readRepoConfig(repoDir string) error {
-	p := filepath.Join(repoDir, r.Options.LinterConfig.Run.Config)
-	r.Options.Logger.Debug("trying to read linter config file %s", p)
-	file, err := os.Open(p)
+// applyConfig merges the config file (named name) into the linter config and the templates, the settings of a
+// repository config (repo) are restricted by Options.ConfigPolicy.
+func (r *Runner) applyConfig(file io.Reader, name string, repo bool) error {
+	var cfg config.Config
+
+	content, err := ioutil.ReadAll(file)
 	if err != nil {
-		if os.IsNotExist(err) {
-			r.Options.Logger.Debug("no config file present")
-			return nil
+		return fmt.Errorf("unable to read %s: %w", name, err)
+	}
+	doc, unknown, err := checkConfig(name, content)
+	if err != nil {
+		return err
+	}
+	for _, problem := range unknown {
+		r.Options.Logger.Warn("%s line %d: %s", name, problem.Line, problem.Message)
+		if repo {
+			r.unknownSettings = append(r.unknownSettings, fmt.Sprintf("%s (line %d)", strings.TrimPrefix(problem.Message, "unknown setting "), problem.Line))
 		}
 	}
-	defer file.Close()
-
-	var cfg config.Config
 
 	v := viper.New()
 	v.SetConfigType("yaml")
-	if err := v.ReadConfig(file); err != nil {
-		return err
-	}
-	if err := v.Unmarshal(&cfg); err != nil {
-		return err
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return configError(name, err)
 	}
 
-	if err = mergo.Merge(&r.Options.LinterConfig, cfg, mergo.WithOverride); err != nil {
-		return nil
+	if repo && r.Options.ConfigPolicy != nil && doc != nil {
+		if forbidden := forbiddenSettings(r.Options.ConfigPolicy, doc, ""); len(forbidden) > 0 {
+			if v, err = r.applyPolicy(v, name, forbidden); err != nil {
+				return err
+			}
+		}
+	}
+	if err := v.Unmarshal(&cfg); err != nil {
+		return configError(name, err)
 	}
 
-	buf, err := json.Marshal(r.Options.LinterConfig)
+	settings := v.AllSettings()
+	strategies, err := mergeStrategies(r.Options.MergeStrategies, settings)
 	if err != nil {
-		return err
+		return configError(name, fmt.Errorf("invalid merge strategies: %w", err))
+	}
+	if err := mergeConfig(&r.Options.LinterConfig, &cfg, settings, strategies); err != nil {
+		return configError(name, err)
 	}
 
-	r.Options.Logger.Debug("successfully read config %s: %s", p, string(buf))
-	return nil
-}
-
-type appTransport struct {
-	underlyingTransport http.RoundTripper
-	token               string
-}
-
-func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Add("Accept", "application/vnd.github.machine-man-preview+json")
-	req.Header.Add("Authorization", "Bearer "+t.token)
-	return t.underlyingTransport.RoundTrip(req)
-}
+	if version := v.GetString(linterVersionKey); version != "" {
+		r.requiredVersion = version
+	}
 
-func makeAppClient(appID int64, privateKey *rsa.PrivateKey) (*github.Client, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.StandardClaims{
-		ExpiresAt: time.Now().Local().Add(time.Minute * 5).Unix(),
-		IssuedAt:  time.Now().Unix(),
-		Issuer:    strconv.FormatInt(appID, 10),
-	})
+	var repoTemplates Templates
+	if err := v.UnmarshalKey(repoTemplatesKey, &repoTemplates); err != nil {
+		return configError(name, err)
+	}
+	if repoTemplates != (Templates{}) {
+		if r.Options.Templates, err = r.Options.Templates.merge(repoTemplates); err != nil {
+			return err
+		}
+		if r.templates, err = parseTemplates(r.Options.Templates); err != nil {
+			return configError(name, fmt.Errorf("invalid %s: %w", repoTemplatesKey, err))
+		}
+	}
 
-	tokenString, err := token.SignedString(privateKey)
+	buf, err := json.Marshal(r.Options.LinterConfig)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return github.NewClient(&http.Client{Transport: &appTransport{underlyingTransport: http.DefaultTransport, token: tokenString}}), nil
-}
-
-type installationTransport struct {
-	underlyingTransport http.RoundTripper
-	token               string
-}
 
-func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Add("Accept", "application/vnd.github.machine-man-preview+json")
-	req.Header.Add("Authorization", "token "+t.token)
-	return t.underlyingTransport.RoundTrip(req)
-}
-
-func makeInstallationClient(token string) (*github.Client, error) {
-	return github.NewClient(&http.Client{Transport: &installationTransport{underlyingTransport: http.DefaultTransport, token: token}}), nil
+	r.Options.Logger.Debug("successfully read config %s: %s", name, string(buf))
+	return nil
 }
@@ -12,7 +12,6 @@ import (
 	"path/filepath"
 
 	"github.com/golangci/golangci-lint/pkg/config"
-	jsoniter "github.com/json-iterator/go"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,7 +38,7 @@ func TestRunner_readRepoConfig(t *testing.T) {
 		name         string
 		fields       fields
 		wantErr      bool
-		repoConfig   *config.Config
+		repoConfig   string
 		expectConfig config.Config
 	}{
 		{
@@ -55,7 +54,7 @@ func TestRunner_readRepoConfig(t *testing.T) {
 				},
 			},
 			wantErr:      false,
-			repoConfig:   nil,
+			repoConfig:   "",
 			expectConfig: defaultConfig,
 		},
 
@@ -71,12 +70,8 @@ func TestRunner_readRepoConfig(t *testing.T) {
 					LinterConfig: defaultConfig,
 				},
 			},
-			repoConfig: &config.Config{
-				Linters: config.Linters{
-					Enable: []string{"Bye"},
-				},
-			},
-			wantErr: false,
+			repoConfig: "linters:\n  enable: [Bye]\n",
+			wantErr:    false,
 			expectConfig: config.Config{
 				Run: config.Run{
 					Config: ".golangci.yml",
@@ -105,25 +100,8 @@ func TestRunner_readRepoConfig(t *testing.T) {
 				Options: tt.fields.Options,
 			}
 
-			if tt.repoConfig != nil {
-				file, err := os.Create(filepath.Join(dir, tt.fields.Options.LinterConfig.Run.Config))
-				if err != nil {
-					t.Fatal()
-					return
-				}
-				var json = jsoniter.Config{
-					EscapeHTML:             true,
-					SortMapKeys:            true,
-					ValidateJsonRawMessage: true,
-					TagKey:                 "mapstructure",
-				}.Froze()
-				err = json.NewEncoder(file).Encode(tt.repoConfig)
-				if err != nil {
-					file.Close()
-					t.Fatal()
-					return
-				}
-				file.Close()
+			if tt.repoConfig != "" {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, tt.fields.Options.LinterConfig.Run.Config), []byte(tt.repoConfig), 0600))
 			}
 
 			if err := r.readRepoConfig(dir); (err != nil) != tt.wantErr {
@@ -28,7 +28,13 @@ type ServerOptions struct {
 	WebhookSecret string
 	webHookSecret []byte
 	AppID         int64
-	QueueSize     int
+	// GitHubURL is the api url of GitHub Enterprise Server, github.com is used if it is empty
+	GitHubURL string
+	QueueSize int
+	// GitLab and Gitea enable merge requests of GitLab and pull requests of Gitea,
+	// the GitHub app options are optional if one of them is set
+	GitLab *GitLabOptions
+	Gitea  *GiteaOptions
 	*Options
 }
 
@@ -48,15 +54,39 @@ func NewServer(options *ServerOptions) (*Server, error) {
 	if options == nil {
 		return nil, errors.New("Options must be specified")
 	}
-	if options.PrivateKey == nil {
-		return nil, errors.New("PrivateKey must be specified")
+	if options.GitLab == nil && options.Gitea == nil || options.PrivateKey != nil || options.WebhookSecret != "" || options.AppID != 0 {
+		if options.PrivateKey == nil {
+			return nil, errors.New("PrivateKey must be specified")
+		}
+		if options.WebhookSecret == "" {
+			return nil, errors.New("WebhookSecret must be specified")
+		}
+		options.webHookSecret = []byte(options.WebhookSecret)
+		if options.AppID == 0 {
+			return nil, errors.New("AppID must be specified")
+		}
+		if _, err := NewGitHubClient(options.GitHubURL, nil); err != nil {
+			return nil, err
+		}
 	}
-	if options.WebhookSecret == "" {
-		return nil, errors.New("WebhookSecret must be specified")
+	if options.GitLab != nil {
+		if options.GitLab.Token == "" {
+			return nil, errors.New("GitLab.Token must be specified")
+		}
+		if options.GitLab.WebhookSecret == "" {
+			return nil, errors.New("GitLab.WebhookSecret must be specified")
+		}
 	}
-	options.webHookSecret = []byte(options.WebhookSecret)
-	if options.AppID == 0 {
-		return nil, errors.New("AppID must be specified")
+	if options.Gitea != nil {
+		if options.Gitea.URL == "" {
+			return nil, errors.New("Gitea.URL must be specified")
+		}
+		if options.Gitea.Token == "" {
+			return nil, errors.New("Gitea.Token must be specified")
+		}
+		if options.Gitea.WebhookSecret == "" {
+			return nil, errors.New("Gitea.WebhookSecret must be specified")
+		}
 	}
 	if options.Logger == nil {
 		return nil, errors.New("Logger must be specified")
@@ -64,6 +94,10 @@ func NewServer(options *ServerOptions) (*Server, error) {
 	if options.Timeout <= 0 {
 		options.Timeout = time.Minute * 10
 	}
+	if options.OrgConfigCache == nil {
+		// shared by all jobs
+		options.OrgConfigCache = NewOrgConfigCache()
+	}
 	return &Server{
 		queue:     make(chan *Runner, options.QueueSize),
 		queueSize: options.QueueSize,
@@ -75,6 +109,8 @@ func (srv *Server) HttpHandler() http.Handler {
 	srv.startQueue()
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", srv.handler(srv.handleEvent))
+	mux.HandleFunc("/gitlab", srv.handler(srv.handleGitLabEvent))
+	mux.HandleFunc("/gitea", srv.handler(srv.handleGiteaEvent))
 	return mux
 }
 
@@ -86,10 +122,12 @@ func (srv *Server) startQueue() {
 
 func (srv *Server) workQueue() {
 	for runner := range srv.queue {
-		runner.Options.Context, _ = context.WithTimeout(context.Background(), srv.Options.Timeout)
+		var cancel context.CancelFunc
+		runner.Options.Context, cancel = context.WithTimeout(context.Background(), srv.Options.Timeout)
 		if err := runner.Run(); err != nil {
-			srv.Options.Logger.Error("runner failed: %s", err.Error())
+			runner.Options.Logger.Error("runner failed: %s", err.Error())
 		}
+		cancel()
 	}
 }
 
@@ -190,10 +228,10 @@ func (srv *Server) handlePullRequestOpened(writer http.ResponseWriter, request *
 		}
 	}
 
-	appClient, err := makeAppClient(srv.Options.AppID, srv.Options.PrivateKey)
+	appClient, err := makeAppClient(srv.Options.GitHubURL, srv.Options.AppID, srv.Options.PrivateKey)
 	if err != nil {
 		return internal.WireError{
-			PrivateError: fmt.Errorf("unable to create client"),
+			PrivateError: fmt.Errorf("unable to create client: %w", err),
 		}
 	}
 
@@ -204,9 +242,16 @@ func (srv *Server) handlePullRequestOpened(writer http.ResponseWriter, request *
 		}
 	}
 
-	ctx, _ := context.WithTimeout(context.Background(), srv.Options.Timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), srv.Options.Timeout)
+	defer cancel()
+
+	opts := *srv.Options.Options
+	opts.Logger = withFields(opts.Logger, Fields{
+		"delivery":     github.DeliveryID(request),
+		"installation": installationID,
+	})
 
-	srv.Options.Logger.Debug("creating installation token")
+	opts.Logger.Debug("creating installation token")
 	// todo: we can store this token for a later use
 	installationToken, _, err := appClient.Apps.CreateInstallationToken(ctx, installationID)
 	if err != nil {
@@ -215,7 +260,6 @@ func (srv *Server) handlePullRequestOpened(writer http.ResponseWriter, request *
 		}
 	}
 
-	opts := *srv.Options.Options
 	opts.Context = ctx
 	opts.CloneToken = installationToken.GetToken()
 	if opts.CloneToken == "" {
@@ -224,14 +268,22 @@ func (srv *Server) handlePullRequestOpened(writer http.ResponseWriter, request *
 		}
 	}
 
-	opts.Client, err = makeInstallationClient(opts.CloneToken)
+	opts.Client, err = makeInstallationClient(srv.Options.GitHubURL, opts.CloneToken)
 	if err != nil {
 		return internal.WireError{
-			PrivateError: fmt.Errorf("unable to create client"),
+			PrivateError: fmt.Errorf("unable to create client: %w", err),
 		}
 	}
 
 	opts.PullRequest = pr
+	return srv.enqueue(opts)
+}
+
+// enqueue creates a runner and adds it to the queue.
+func (srv *Server) enqueue(opts Options) error {
+	ctx, cancel := context.WithTimeout(context.Background(), srv.Options.Timeout)
+	defer cancel()
+	opts.Context = ctx
 
 	runner, err := NewRunner(opts)
 	if err != nil {
@@ -243,7 +295,7 @@ func (srv *Server) handlePullRequestOpened(writer http.ResponseWriter, request *
 
 	select {
 	case srv.queue <- runner:
-		srv.Options.Logger.Debug("added job to queue (%d/%d)", len(srv.queue), srv.queueSize)
+		runner.Options.Logger.Debug("added job to queue (%d/%d)", len(srv.queue), srv.queueSize)
 		return nil
 	default:
 		return internal.WireError{
@@ -0,0 +1,52 @@
+package golangci_lint_runner
+
+import (
+	"strings"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+)
+
+// Severities of issues, errors request changes, warnings are commented and info issues are only listed in the summary.
+// The severity of an issue is configured with the severity section of the golangci-lint config.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// issueSeverity maps the severity golangci-lint reported for the issue to SeverityError, SeverityWarning or SeverityInfo.
+// Issues without or with an unknown severity are errors.
+func issueSeverity(issue *result.Issue) string {
+	switch strings.ToLower(strings.TrimSpace(issue.Severity)) {
+	case "warning", "warn", "minor":
+		return SeverityWarning
+	case "info", "information", "note", "notice", "hint", "suggestion":
+		return SeverityInfo
+	default:
+		return SeverityError
+	}
+}
+
+// severityRank orders the severities, errors first.
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// splitInfoIssues splits the issues into info issues and the others.
+func splitInfoIssues(issues []result.Issue) (others, info []result.Issue) {
+	for i := range issues {
+		if issueSeverity(&issues[i]) == SeverityInfo {
+			info = append(info, issues[i])
+		} else {
+			others = append(others, issues[i])
+		}
+	}
+	return others, info
+}
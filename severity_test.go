@@ -0,0 +1,40 @@
+package golangci_lint_runner
+
+import (
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueSeverity(t *testing.T) {
+	tests := []struct {
+		severity string
+		expect   string
+	}{
+		{severity: "", expect: SeverityError},
+		{severity: "error", expect: SeverityError},
+		{severity: "blocker", expect: SeverityError},
+		{severity: "warning", expect: SeverityWarning},
+		{severity: "Warn", expect: SeverityWarning},
+		{severity: "minor", expect: SeverityWarning},
+		{severity: "info", expect: SeverityInfo},
+		{severity: " INFO ", expect: SeverityInfo},
+		{severity: "notice", expect: SeverityInfo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			require.Equal(t, tt.expect, issueSeverity(&result.Issue{Severity: tt.severity}))
+		})
+	}
+}
+
+func TestSplitInfoIssues(t *testing.T) {
+	others, info := splitInfoIssues([]result.Issue{
+		{FromLinter: "errcheck"},
+		{FromLinter: "golint", Severity: "info"},
+		{FromLinter: "misspell", Severity: "warning"},
+	})
+	require.Equal(t, []result.Issue{{FromLinter: "errcheck"}, {FromLinter: "misspell", Severity: "warning"}}, others)
+	require.Equal(t, []result.Issue{{FromLinter: "golint", Severity: "info"}}, info)
+}
@@ -0,0 +1,229 @@
+package golangci_lint_runner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/golangci/golangci-lint/pkg/report"
+	"github.com/golangci/golangci-lint/pkg/result"
+)
+
+const defaultSummaryTemplate = `{{ if .Title }}{{ .Title }}
+{{ end }}
+{{- if .Autofix }}
+{{ .Autofix }}
+{{ end }}
+{{- if .UnavailableVersion }}
+:information_source: golangci-lint {{ .UnavailableVersion }} is not available, the default version was used.
+{{ end }}
+{{- if .IgnoredSettings }}
+:no_entry: These settings of the lint config are not allowed and were ignored: {{ join .IgnoredSettings ", " }}
+{{ end }}
+{{- if .UnknownSettings }}
+:warning: These settings of the lint config are unknown and were ignored: {{ join .UnknownSettings ", " }}
+{{ end }}
+{{- if .ConfigChanged }}
+:warning: This pull request changes the lint config ` + "`{{ .ConfigChanged }}`" + `{{ if eq .ConfigSource "base" }}, the config of the base branch was used{{ end }}.
+{{ end }}
+{{- if .Issues }}
+| Linter | Issues |
+| --- | ---: |
+{{ range .Linters }}| {{ cell .Name }} | {{ .Count }} |
+{{ end }}
+| File | Issues |
+| --- | ---: |
+{{ range .Files }}| {{ cell .Name }} | {{ .Count }} |
+{{ end }}
+{{- end }}
+{{- if .Overflow }}
+{{ len .Overflow }} more issues not shown:
+{{ range first .Overflow }}* {{ .FilePath }}:{{ .Line }}: {{ .Text }}
+{{ end }}{{ more .Overflow }}
+{{- end }}
+{{- if .OtherFiles }}
+{{ len .OtherFiles }} issues in unchanged files:
+{{ range first .OtherFiles }}* {{ .FilePath }}:{{ .Line }}: {{ .Text }}
+{{ end }}{{ more .OtherFiles }}
+{{- end }}
+{{- if .SameFile }}
+<details>
+<summary>{{ len .SameFile }} issues outside of the changed lines</summary>
+
+{{ range first .SameFile }}* {{ .FilePath }}:{{ .Line }}: {{ .Text }}
+{{ end }}{{ more .SameFile }}
+</details>
+{{ end }}
+{{- if .Info }}
+<details>
+<summary>{{ len .Info }} info issues</summary>
+
+{{ range first .Info }}* {{ .FilePath }}:{{ .Line }}: {{ .Text }}
+{{ end }}{{ more .Info }}
+</details>
+{{ end }}
+{{- if .Warnings }}
+<details>
+<summary>{{ len .Warnings }} warnings</summary>
+
+{{ $fence := fence .WarningsText }}{{ $fence }}
+{{ .WarningsText }}
+{{ $fence }}
+
+</details>
+{{ end }}
+<sub>golangci-lint{{ if .Version }} {{ .Version }}{{ end }}{{ if .EnabledLinters }} with {{ join .EnabledLinters ", " }}{{ end }} took {{ .Duration }}</sub>
+`
+
+// maxListedIssues is the maximum number of issues listed per section, to keep the summary short.
+const maxListedIssues = 30
+
+var versionRegexp = regexp.MustCompile(`version v?([^\s]+)`)
+
+// Count is the number of issues for a linter or file.
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Summary is the data that is available in the summary of a review.
+type Summary struct {
+	PullRequest MetaData
+	// Title is the headline, e.g. "golangci-lint found 3 issues"
+	Title string
+	// Autofix describes what was fixed in autofix mode
+	Autofix string
+	// ConfigChanged is the config file of the repository if the pull request changes it
+	ConfigChanged string
+	// ConfigSource is the commit the config was read from, ConfigSourceHead or ConfigSourceBase
+	ConfigSource string
+	// IgnoredSettings of the repository config that the config policy does not allow
+	IgnoredSettings []string
+	// UnknownSettings of the repository config (with their line), golangci-lint ignores them
+	UnknownSettings []string
+	// UnavailableVersion is the golangci-lint version the repository requires if it is not installed
+	UnavailableVersion string
+	// Issues are all errors and warnings in the changed lines
+	Issues []result.Issue
+	// Overflow are the new issues that were not commented because of the comment limits
+	Overflow []result.Issue
+	// SameFile are the issues in changed files outside of the changed lines
+	SameFile []result.Issue
+	// OtherFiles are the typecheck issues in unchanged files
+	OtherFiles []result.Issue
+	// Info are the info issues in the changed lines, they are not commented
+	Info []result.Issue
+	// NewIssues is the number of issues that were not reported before
+	NewIssues      int
+	Linters        []Count
+	Files          []Count
+	Warnings       []report.Warning
+	Version        string
+	EnabledLinters []string
+	Duration       time.Duration
+}
+
+// WarningsText returns the warnings one per line.
+func (s *Summary) WarningsText() string {
+	var sb strings.Builder
+	for i, w := range s.Warnings {
+		if i > 0 {
+			sb.WriteRune('\n')
+		}
+		if w.Tag != "" {
+			sb.WriteString(w.Tag)
+			sb.WriteString(": ")
+		}
+		sb.WriteString(strings.TrimSpace(w.Text))
+	}
+	return sb.String()
+}
+
+// IsEmpty returns true if there is nothing to report.
+func (s *Summary) IsEmpty() bool {
+	return s.Title == "" && s.Autofix == "" && s.NewIssues == 0 && len(s.SameFile) == 0 && len(s.OtherFiles) == 0 && len(s.Info) == 0 && len(s.Warnings) == 0 &&
+		s.ConfigChanged == "" && len(s.IgnoredSettings) == 0 && len(s.UnknownSettings) == 0 &&
+		s.UnavailableVersion == ""
+}
+
+func newSummary(issues []result.Issue, rep *report.Data) *Summary {
+	s := Summary{
+		Issues:  issues,
+		Linters: countIssues(issues, func(i *result.Issue) string { return i.FromLinter }),
+		Files:   countIssues(issues, func(i *result.Issue) string { return i.FilePath() }),
+	}
+	if rep != nil {
+		s.Warnings = rep.Warnings
+		for _, l := range rep.Linters {
+			if l.Enabled {
+				s.EnabledLinters = append(s.EnabledLinters, l.Name)
+			}
+		}
+	}
+	return &s
+}
+
+// countIssues counts the issues by key, sorted by count and name.
+func countIssues(issues []result.Issue, key func(*result.Issue) string) []Count {
+	m := make(map[string]int)
+	for i := range issues {
+		m[key(&issues[i])]++
+	}
+	counts := make([]Count, 0, len(m))
+	for name, count := range m {
+		counts = append(counts, Count{Name: name, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// render renders the summary with t, an empty string is returned if there is nothing to report.
+func (s *Summary) render(t *template.Template) (string, error) {
+	if s.IsEmpty() {
+		return "", nil
+	}
+	return execute(t, s)
+}
+
+// firstIssues returns the first maxListedIssues issues.
+func firstIssues(issues []result.Issue) []result.Issue {
+	if len(issues) > maxListedIssues {
+		return issues[:maxListedIssues]
+	}
+	return issues
+}
+
+// moreIssues returns a list item with the number of issues that are not listed.
+func moreIssues(issues []result.Issue) string {
+	if len(issues) <= maxListedIssues {
+		return ""
+	}
+	return fmt.Sprintf("* and %d more\n", len(issues)-maxListedIssues)
+}
+
+// markdownCell escapes s so it can be used in a markdown table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// linterVersion returns the version of golangci-lint.
+func (runner *Runner) linterVersion(workDir, repoDir string) string {
+	out, err := runner.execLinter(runner.Options.CacheDir, workDir, repoDir, "--version")
+	if err != nil {
+		runner.Options.Logger.Debug("unable to get golangci-lint version: %s", err)
+		return ""
+	}
+	m := versionRegexp.FindSubmatch(out)
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
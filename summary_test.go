@@ -0,0 +1,237 @@
+package golangci_lint_runner
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golangci/golangci-lint/pkg/report"
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	issues := []result.Issue{
+		{FromLinter: "errcheck", Pos: token.Position{Filename: "main.go", Line: 1}},
+		{FromLinter: "golint", Pos: token.Position{Filename: "main.go", Line: 2}},
+		{FromLinter: "golint", Pos: token.Position{Filename: "a|b.go", Line: 3}},
+	}
+	rep := &report.Data{
+		Warnings: []report.Warning{{Tag: "runner", Text: "can't run linter goanalysis_metalinter\n"}},
+		Linters: []report.LinterData{
+			{Name: "errcheck", Enabled: true},
+			{Name: "golint", Enabled: true},
+			{Name: "gosec"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		summary func() *Summary
+		want    string
+	}{
+		{
+			name: "empty",
+			summary: func() *Summary {
+				return newSummary(nil, nil)
+			},
+			want: "",
+		},
+		{
+			name: "no new issues",
+			summary: func() *Summary {
+				return newSummary(issues, nil)
+			},
+			want: "",
+		},
+		{
+			name: "issues",
+			summary: func() *Summary {
+				s := newSummary(issues, rep)
+				s.Title = "golangci-lint found 3 issues"
+				s.NewIssues = 3
+				s.Version = "1.30.0"
+				s.Duration = 2 * time.Second
+				return s
+			},
+			want: "golangci-lint found 3 issues\n" +
+				"\n" +
+				"| Linter | Issues |\n" +
+				"| --- | ---: |\n" +
+				"| golint | 2 |\n" +
+				"| errcheck | 1 |\n" +
+				"\n" +
+				"| File | Issues |\n" +
+				"| --- | ---: |\n" +
+				"| main.go | 2 |\n" +
+				"| a\\|b.go | 1 |\n" +
+				"\n" +
+				"<details>\n" +
+				"<summary>1 warnings</summary>\n" +
+				"\n" +
+				"```\n" +
+				"runner: can't run linter goanalysis_metalinter\n" +
+				"```\n" +
+				"\n" +
+				"</details>\n" +
+				"\n" +
+				"<sub>golangci-lint 1.30.0 with errcheck, golint took 2s</sub>",
+		},
+		{
+			name: "overflow",
+			summary: func() *Summary {
+				s := newSummary(issues[:1], nil)
+				s.Title = "golangci-lint found 2 issues"
+				s.NewIssues = 2
+				s.Overflow = []result.Issue{{FromLinter: "golint", Text: "exported function Foo should have comment", Pos: token.Position{Filename: "main.go", Line: 2}}}
+				s.Duration = time.Second
+				return s
+			},
+			want: "golangci-lint found 2 issues\n" +
+				"\n" +
+				"| Linter | Issues |\n" +
+				"| --- | ---: |\n" +
+				"| errcheck | 1 |\n" +
+				"\n" +
+				"| File | Issues |\n" +
+				"| --- | ---: |\n" +
+				"| main.go | 1 |\n" +
+				"\n" +
+				"1 more issues not shown:\n" +
+				"* main.go:2: exported function Foo should have comment\n" +
+				"\n" +
+				"<sub>golangci-lint took 1s</sub>",
+		},
+		{
+			name: "outside of the diff",
+			summary: func() *Summary {
+				s := newSummary(nil, nil)
+				s.OtherFiles = []result.Issue{{FromLinter: "typecheck", Text: "undeclared name: Foo", Pos: token.Position{Filename: "other.go", Line: 3}}}
+				for i := 0; i < maxListedIssues+2; i++ {
+					s.SameFile = append(s.SameFile, result.Issue{FromLinter: "golint", Text: "issue", Pos: token.Position{Filename: "main.go", Line: i + 1}})
+				}
+				s.Duration = time.Second
+				return s
+			},
+			want: func() string {
+				var sb strings.Builder
+				sb.WriteString("1 issues in unchanged files:\n")
+				sb.WriteString("* other.go:3: undeclared name: Foo\n")
+				sb.WriteString("\n")
+				sb.WriteString("<details>\n")
+				fmt.Fprintf(&sb, "<summary>%d issues outside of the changed lines</summary>\n\n", maxListedIssues+2)
+				for i := 0; i < maxListedIssues; i++ {
+					fmt.Fprintf(&sb, "* main.go:%d: issue\n", i+1)
+				}
+				sb.WriteString("* and 2 more\n")
+				sb.WriteString("\n")
+				sb.WriteString("</details>\n")
+				sb.WriteString("\n")
+				sb.WriteString("<sub>golangci-lint took 1s</sub>")
+				return sb.String()
+			}(),
+		},
+		{
+			name: "same file only",
+			summary: func() *Summary {
+				s := newSummary(nil, nil)
+				s.SameFile = []result.Issue{{FromLinter: "typecheck", Text: "undeclared name: Foo", Pos: token.Position{Filename: "main.go", Line: 3}}}
+				s.Duration = time.Second
+				return s
+			},
+			want: "<details>\n" +
+				"<summary>1 issues outside of the changed lines</summary>\n\n" +
+				"* main.go:3: undeclared name: Foo\n" +
+				"\n" +
+				"</details>\n" +
+				"\n" +
+				"<sub>golangci-lint took 1s</sub>",
+		},
+		{
+			name: "info issues",
+			summary: func() *Summary {
+				s := newSummary(nil, nil)
+				s.Info = []result.Issue{{FromLinter: "golint", Text: "comment on exported function", Pos: token.Position{Filename: "main.go", Line: 4}}}
+				s.Duration = time.Second
+				return s
+			},
+			want: "<details>\n" +
+				"<summary>1 info issues</summary>\n" +
+				"\n" +
+				"* main.go:4: comment on exported function\n" +
+				"\n" +
+				"</details>\n" +
+				"\n" +
+				"<sub>golangci-lint took 1s</sub>",
+		},
+		{
+			name: "unavailable version",
+			summary: func() *Summary {
+				s := newSummary(nil, nil)
+				s.UnavailableVersion = "1.32.0"
+				s.Version = "1.30.0"
+				s.Duration = time.Second
+				return s
+			},
+			want: ":information_source: golangci-lint 1.32.0 is not available, the default version was used.\n\n" +
+				"<sub>golangci-lint 1.30.0 took 1s</sub>",
+		},
+		{
+			name: "ignored settings",
+			summary: func() *Summary {
+				s := newSummary(nil, nil)
+				s.IgnoredSettings = []string{"run.skip-dirs", "linters-settings.custom"}
+				s.Duration = time.Second
+				return s
+			},
+			want: ":no_entry: These settings of the lint config are not allowed and were ignored: run.skip-dirs, linters-settings.custom\n\n" +
+				"<sub>golangci-lint took 1s</sub>",
+		},
+		{
+			name: "unknown settings",
+			summary: func() *Summary {
+				s := newSummary(nil, nil)
+				s.UnknownSettings = []string{"linters.enabled (line 2)"}
+				s.Duration = time.Second
+				return s
+			},
+			want: ":warning: These settings of the lint config are unknown and were ignored: linters.enabled (line 2)\n\n" +
+				"<sub>golangci-lint took 1s</sub>",
+		},
+		{
+			name: "config changed",
+			summary: func() *Summary {
+				s := newSummary(nil, nil)
+				s.Title = "no issues"
+				s.ConfigChanged = ".golangci.yml"
+				s.ConfigSource = ConfigSourceBase
+				s.Duration = time.Second
+				return s
+			},
+			want: "no issues\n\n" +
+				":warning: This pull request changes the lint config `.golangci.yml`, the config of the base branch was used.\n\n" +
+				"<sub>golangci-lint took 1s</sub>",
+		},
+		{
+			name: "no issues",
+			summary: func() *Summary {
+				s := newSummary(nil, nil)
+				s.Title = "no issues"
+				s.Duration = time.Second
+				return s
+			},
+			want: "no issues\n\n<sub>golangci-lint took 1s</sub>",
+		},
+	}
+	templates, err := parseTemplates(Templates{})
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.summary().render(templates.summary)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package golangci_lint_runner
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/golangci/golangci-lint/pkg/report"
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/imdario/mergo"
+	"github.com/spf13/viper"
+)
+
+// Templates are text/template templates for all messages the runner posts.
+// Empty templates fall back to DefaultTemplates, an empty result is not posted.
+type Templates struct {
+	// NoChanges is posted if the pull request contains no go code changes (data: *Summary)
+	NoChanges string `mapstructure:"no-changes"`
+	// NoIssues is the summary title if no issues were found (data: *Summary)
+	NoIssues string `mapstructure:"no-issues"`
+	// NoNewIssues is the summary title if all issues were reported before (data: *Summary)
+	NoNewIssues string `mapstructure:"no-new-issues"`
+	// Issues is the summary title if new issues were found (data: *Summary)
+	Issues string `mapstructure:"issues"`
+	// Summary is the body of the review (data: *Summary)
+	Summary string `mapstructure:"summary"`
+	// Comment is the text of an issue comment, suggestions are appended (data: *CommentData)
+	Comment string `mapstructure:"comment"`
+	// OutdatedReply is the reply to comments whose issue is no longer reported (data: *OutdatedData)
+	OutdatedReply string `mapstructure:"outdated-reply"`
+	// InvalidConfig is posted if a config file is invalid (data: *InvalidConfigData)
+	InvalidConfig string `mapstructure:"invalid-config"`
+	// AutofixPushed is added to the summary if fixes were pushed to the pull request (data: *AutofixData)
+	AutofixPushed string `mapstructure:"autofix-pushed"`
+	// AutofixPullRequest is added to the summary if a pull request with fixes was opened (data: *AutofixData)
+	AutofixPullRequest string `mapstructure:"autofix-pull-request"`
+	// AutofixFork is added to the summary if the issues of a pull request from a fork are not fixed (data: *AutofixData)
+	AutofixFork string `mapstructure:"autofix-fork"`
+	// FixPullRequestTitle and FixPullRequestBody are the title and body of the pull request with fixes (data: *AutofixData)
+	FixPullRequestTitle string `mapstructure:"fix-pull-request-title"`
+	FixPullRequestBody  string `mapstructure:"fix-pull-request-body"`
+}
+
+// repoTemplatesKey is the key of the templates in the repository config file.
+const repoTemplatesKey = "golangci-lint-runner.templates"
+
+// DefaultTemplates are used for all templates that are not set.
+var DefaultTemplates = Templates{
+	Issues:        `golangci-lint found {{ .NewIssues }} {{ if ne .NewIssues (len .Issues) }}new {{ end }}issues`,
+	Summary:       defaultSummaryTemplate,
+	Comment:       `{{ .Issue.Text }}`,
+	OutdatedReply: `This issue is no longer reported by golangci-lint.`,
+	InvalidConfig: defaultInvalidConfigTemplate,
+
+	AutofixPushed:       `golangci-lint-runner fixed {{ len .Issues }} issues in {{ .Commit }}`,
+	AutofixPullRequest:  `golangci-lint-runner opened {{ .Ref }} to fix {{ len .Issues }} issues`,
+	AutofixFork:         `golangci-lint-runner did not fix {{ len .Issues }} issues because the pull request is from the fork {{ .PullRequest.Head.FullName }}`,
+	FixPullRequestTitle: `golangci-lint fixes for {{ .PullRequest.Reference }}`,
+	FixPullRequestBody:  `Fixes the issues golangci-lint found in {{ .PullRequest.Reference }}.`,
+}
+
+const defaultInvalidConfigTemplate = `golangci-lint did not run because ` + "`{{ .Error.File }}`" + ` is invalid:
+{{ range .Error.Problems }}
+- {{ if .Line }}line {{ .Line }}: {{ end }}{{ .Message }}
+{{- end }}`
+
+// CommentData is the data that is available in the Comment template.
+type CommentData struct {
+	PullRequest MetaData
+	Issue       *result.Issue
+	// Severity is SeverityError or SeverityWarning
+	Severity string
+}
+
+// AutofixData is the data that is available in the autofix templates.
+type AutofixData struct {
+	PullRequest MetaData
+	// Issues that were fixed (the fixable issues for AutofixFork)
+	Issues []result.Issue
+	// Commit with the fixes and Ref of the pull request with the fixes (once it was opened)
+	Commit string
+	Ref    string
+}
+
+// OutdatedData is the data that is available in the OutdatedReply template.
+type OutdatedData struct {
+	PullRequest MetaData
+	// Path and Body of the outdated comment
+	Path string
+	Body string
+}
+
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"fence": codeFence,
+	"cell":  markdownCell,
+	"first": firstIssues,
+	"more":  moreIssues,
+}
+
+type templates struct {
+	noChanges     *template.Template
+	noIssues      *template.Template
+	noNewIssues   *template.Template
+	issues        *template.Template
+	summary       *template.Template
+	comment       *template.Template
+	outdatedReply *template.Template
+	invalidConfig *template.Template
+
+	autofixPushed       *template.Template
+	autofixPullRequest  *template.Template
+	autofixFork         *template.Template
+	fixPullRequestTitle *template.Template
+	fixPullRequestBody  *template.Template
+}
+
+// Validate parses the templates and executes them with example data.
+func (t Templates) Validate() error {
+	_, err := parseTemplates(t)
+	return err
+}
+
+// ReadTemplates reads templates from a yaml file.
+func ReadTemplates(r io.Reader) (Templates, error) {
+	var t Templates
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(r); err != nil {
+		return t, err
+	}
+	if err := v.Unmarshal(&t); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// withTexts returns t with the texts of the deprecated Options.NoChangesText, NoIssuesText and NoNewIssuesText
+// for the templates that are not set.
+func (t Templates) withTexts(noChanges, noIssues, noNewIssues string) Templates {
+	if t.NoChanges == "" {
+		t.NoChanges = noChanges
+	}
+	if t.NoIssues == "" {
+		t.NoIssues = noIssues
+	}
+	if t.NoNewIssues == "" {
+		t.NoNewIssues = noNewIssues
+	}
+	return t
+}
+
+// merge returns t with all non empty templates of other.
+func (t Templates) merge(other Templates) (Templates, error) {
+	if err := mergo.Merge(&t, other, mergo.WithOverride); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+func parseTemplates(t Templates) (*templates, error) {
+	t, err := DefaultTemplates.merge(t)
+	if err != nil {
+		return nil, err
+	}
+
+	issue := result.Issue{FromLinter: "golint", Text: "example"}
+	summary := &Summary{
+		Title:          "example",
+		Issues:         []result.Issue{issue},
+		Overflow:       []result.Issue{issue},
+		SameFile:       []result.Issue{issue},
+		OtherFiles:     []result.Issue{issue},
+		Info:           []result.Issue{issue},
+		NewIssues:      1,
+		Linters:        []Count{{Name: issue.FromLinter, Count: 1}},
+		Files:          []Count{{Name: issue.FilePath(), Count: 1}},
+		Warnings:       []report.Warning{{Tag: "runner", Text: "example"}},
+		EnabledLinters: []string{issue.FromLinter},
+	}
+
+	autofix := &AutofixData{Issues: []result.Issue{issue}, Commit: "0123abcd", Ref: "#2"}
+
+	var res templates
+	for _, tt := range []struct {
+		name string
+		text string
+		dst  **template.Template
+		data interface{}
+	}{
+		{name: "no-changes", text: t.NoChanges, dst: &res.noChanges, data: summary},
+		{name: "no-issues", text: t.NoIssues, dst: &res.noIssues, data: summary},
+		{name: "no-new-issues", text: t.NoNewIssues, dst: &res.noNewIssues, data: summary},
+		{name: "issues", text: t.Issues, dst: &res.issues, data: summary},
+		{name: "summary", text: t.Summary, dst: &res.summary, data: summary},
+		{name: "comment", text: t.Comment, dst: &res.comment, data: &CommentData{Issue: &issue, Severity: SeverityError}},
+		{name: "outdated-reply", text: t.OutdatedReply, dst: &res.outdatedReply, data: &OutdatedData{Body: "example"}},
+		{name: "invalid-config", text: t.InvalidConfig, dst: &res.invalidConfig, data: &InvalidConfigData{Error: &ConfigError{File: ".golangci.yml", Problems: []ConfigProblem{{Line: 1, Message: "example"}}}}},
+		{name: "autofix-pushed", text: t.AutofixPushed, dst: &res.autofixPushed, data: autofix},
+		{name: "autofix-pull-request", text: t.AutofixPullRequest, dst: &res.autofixPullRequest, data: autofix},
+		{name: "autofix-fork", text: t.AutofixFork, dst: &res.autofixFork, data: autofix},
+		{name: "fix-pull-request-title", text: t.FixPullRequestTitle, dst: &res.fixPullRequestTitle, data: autofix},
+		{name: "fix-pull-request-body", text: t.FixPullRequestBody, dst: &res.fixPullRequestBody, data: autofix},
+	} {
+		tpl, err := template.New(tt.name).Funcs(templateFuncs).Parse(tt.text)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s template: %w", tt.name, err)
+		}
+		if err := tpl.Execute(ioutil.Discard, tt.data); err != nil {
+			return nil, fmt.Errorf("unable to execute %s template: %w", tt.name, err)
+		}
+		*tt.dst = tpl
+	}
+	return &res, nil
+}
+
+// execute executes the template and trims the result.
+func execute(t *template.Template, data interface{}) (string, error) {
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("unable to execute %s template: %w", t.Name(), err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
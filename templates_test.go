@@ -0,0 +1,66 @@
+package golangci_lint_runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golangci/golangci-lint/pkg/result"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates Templates
+		err       string
+	}{
+		{name: "defaults", templates: Templates{}},
+		{name: "static text", templates: Templates{NoIssues: "No issues found"}},
+		{name: "fields", templates: Templates{Comment: "{{ .Issue.FromLinter }}: {{ .Issue.Text }} in #{{ .PullRequest.PullRequestNumber }}"}},
+		{name: "syntax error", templates: Templates{Summary: "{{ .Title "}, err: "unable to parse summary template"},
+		{name: "unknown function", templates: Templates{NoIssues: "{{ foo }}"}, err: "unable to parse no-issues template"},
+		{name: "unknown field", templates: Templates{Issues: "{{ .Foo }}"}, err: "unable to execute issues template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.templates.Validate()
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestDefaultIssuesTemplate(t *testing.T) {
+	templates, err := parseTemplates(Templates{})
+	require.NoError(t, err)
+
+	issues := make([]result.Issue, 3)
+	title, err := execute(templates.issues, &Summary{Issues: issues, NewIssues: 3})
+	require.NoError(t, err)
+	require.Equal(t, "golangci-lint found 3 issues", title)
+
+	title, err = execute(templates.issues, &Summary{Issues: issues, NewIssues: 1})
+	require.NoError(t, err)
+	require.Equal(t, "golangci-lint found 1 new issues", title)
+}
+
+func TestReadTemplates(t *testing.T) {
+	templates, err := ReadTemplates(strings.NewReader(`
+no-issues: "No issues in #{{ .PullRequest.PullRequestNumber }}"
+outdated-reply: Fixed
+`))
+	require.NoError(t, err)
+	require.Equal(t, Templates{
+		NoIssues:      "No issues in #{{ .PullRequest.PullRequestNumber }}",
+		OutdatedReply: "Fixed",
+	}, templates)
+}
+
+func TestTemplates_withTexts(t *testing.T) {
+	templates := Templates{NoIssues: "No issues"}.withTexts("No changes", "Nothing found", "Nothing new")
+	require.Equal(t, Templates{NoChanges: "No changes", NoIssues: "No issues", NoNewIssues: "Nothing new"}, templates)
+}